@@ -0,0 +1,341 @@
+package broadcast
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/gomodule/redigo/redis"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PrivatePrefix marks a channel as private. Only logged in users that an authorizer allows can
+// subscribe to a private channel, e.g. "private-orders.42"
+const PrivatePrefix = "private-"
+
+// events sent to a client about its own subscriptions
+const (
+	Subscribed        = "subscribed"
+	SubscriptionError = "subscription_error"
+)
+
+// ErrForbidden is returned when subscribing to a channel the user is not authorized for
+var ErrForbidden = errors.New("not authorized for channel")
+
+// Message is an event published on a channel, as it is sent to clients
+type Message struct {
+	Channel string          `json:"channel"`
+	Event   string          `json:"event"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Authorizer decides whether the user with userID may subscribe to a private channel
+type Authorizer func(r *http.Request, userID int, channel string) bool
+
+type authorizer struct {
+	pattern string
+	fn      Authorizer
+}
+
+// Hub keeps track of the clients subscribed to each channel, and sends them the messages
+// published on it. When Pool is set, messages are published through redis, so that clients
+// connected to every instance of the application receive them
+type Hub struct {
+	Logger  *slog.Logger
+	Session *scs.SessionManager // used to find the logged in user for private channels
+	Pool    *redis.Pool
+	Prefix  string // prefix of the redis pub/sub channel
+	Path    string // where Routes is mounted, used by the client script
+
+	mu          sync.RWMutex
+	channels    map[string]map[*client]struct{}
+	authorizers []authorizer
+	pubsub      *redis.PubSubConn
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+// client is a browser connected over a WebSocket or SSE
+type client struct {
+	send     chan Message
+	channels map[string]struct{} // guarded by Hub.mu
+}
+
+// clientBuffer is the number of messages waiting to be written to a client before further
+// messages are dropped
+const clientBuffer = 64
+
+// pingInterval is how often idle connections are pinged, to keep proxies from closing them
+const pingInterval = 25 * time.Second
+
+// New creates a hub. If pool is not nil, messages fan out through redis pub/sub on it
+func New(logger *slog.Logger, session *scs.SessionManager, pool *redis.Pool, prefix string) *Hub {
+	return &Hub{
+		Logger:   logger,
+		Session:  session,
+		Pool:     pool,
+		Prefix:   prefix,
+		Path:     "/broadcasting",
+		channels: make(map[string]map[*client]struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Routes returns the WebSocket (/ws) and SSE (/sse) endpoints, to be mounted on the router at
+// Path, e.g.
+//
+//	app.Routes.Mount("/broadcasting", app.Broadcast.Routes())
+func (h *Hub) Routes() http.Handler {
+	mux := chi.NewRouter()
+	mux.Get("/ws", h.ServeWebSocket)
+	mux.Get("/sse", h.ServeSSE)
+
+	return mux
+}
+
+// Authorize registers fn to decide who may subscribe to the private channels matching
+// pattern, e.g. "private-orders.*". The first matching pattern decides; private channels that
+// match no pattern cannot be subscribed to
+func (h *Hub) Authorize(pattern string, fn Authorizer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.authorizers = append(h.authorizers, authorizer{pattern: pattern, fn: fn})
+}
+
+// Publish sends event, with data encoded as JSON, to the clients subscribed to channel
+func (h *Hub) Publish(channel, event string, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	msg := Message{Channel: channel, Event: event, Data: raw}
+
+	if h.Pool == nil {
+		h.deliver(msg)
+		return nil
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	conn := h.Pool.Get()
+	defer conn.Close()
+
+	// every instance, including this one, delivers the message when redis hands it back
+	_, err = conn.Do("PUBLISH", h.redisChannel(), payload)
+	return err
+}
+
+// Start listens for messages published through redis by any instance of the application. It
+// does nothing when the hub does not use redis
+func (h *Hub) Start() {
+	if h.Pool == nil {
+		return
+	}
+
+	go h.listen()
+}
+
+// Close disconnects all clients and stops listening to redis. It is safe to call more than once
+func (h *Hub) Close() {
+	h.closeOnce.Do(func() {
+		close(h.done)
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.pubsub != nil {
+			_ = h.pubsub.Unsubscribe()
+		}
+	})
+}
+
+func (h *Hub) redisChannel() string {
+	return fmt.Sprintf("%s:broadcast", h.Prefix)
+}
+
+// listen delivers the messages published through redis, reconnecting until the hub is closed
+func (h *Hub) listen() {
+	wait := time.Second
+	for {
+		err := h.receive()
+
+		select {
+		case <-h.done:
+			return
+		default:
+		}
+
+		h.logger().Error("broadcast subscription lost", "error", err, "retry_in", wait)
+		select {
+		case <-h.done:
+			return
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, 30*time.Second)
+	}
+}
+
+// receive subscribes to the redis channel, and delivers messages until the subscription ends
+func (h *Hub) receive() error {
+	conn := h.Pool.Get()
+	defer conn.Close()
+
+	psc := &redis.PubSubConn{Conn: conn}
+	err := psc.Subscribe(h.redisChannel())
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	select {
+	case <-h.done:
+		// closed while subscribing
+		h.mu.Unlock()
+		return nil
+	default:
+	}
+	h.pubsub = psc
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		h.pubsub = nil
+		h.mu.Unlock()
+	}()
+
+	for {
+		switch v := psc.Receive().(type) {
+		case redis.Message:
+			var msg Message
+			if err := json.Unmarshal(v.Data, &msg); err != nil {
+				h.logger().Error("could not decode broadcast message", "error", err)
+				continue
+			}
+			h.deliver(msg)
+		case redis.Subscription:
+			if v.Count == 0 {
+				return nil
+			}
+		case error:
+			return v
+		}
+	}
+}
+
+// deliver sends msg to the clients on this instance that are subscribed to its channel
+func (h *Hub) deliver(msg Message) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for c := range h.channels[msg.Channel] {
+		select {
+		case c.send <- msg:
+		default:
+			h.logger().Warn("dropping broadcast message for slow client", "channel", msg.Channel, "event", msg.Event)
+		}
+	}
+}
+
+// canSubscribe reports whether the user making r may subscribe to channel
+func (h *Hub) canSubscribe(r *http.Request, channel string) bool {
+	if channel == "" {
+		return false
+	}
+
+	if !strings.HasPrefix(channel, PrivatePrefix) {
+		return true
+	}
+
+	if h.Session == nil || !h.Session.Exists(r.Context(), "userID") {
+		return false
+	}
+	userID := h.Session.GetInt(r.Context(), "userID")
+
+	h.mu.RLock()
+	authorizers := h.authorizers
+	h.mu.RUnlock()
+
+	for _, a := range authorizers {
+		if ok, _ := path.Match(a.pattern, channel); ok {
+			return a.fn(r, userID, channel)
+		}
+	}
+
+	return false
+}
+
+func newClient() *client {
+	return &client{
+		send:     make(chan Message, clientBuffer),
+		channels: make(map[string]struct{}),
+	}
+}
+
+// subscribe adds c to channel, if the user making r is authorized for it
+func (h *Hub) subscribe(r *http.Request, c *client, channel string) error {
+	if !h.canSubscribe(r, channel) {
+		return ErrForbidden
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.channels[channel] == nil {
+		h.channels[channel] = make(map[*client]struct{})
+	}
+	h.channels[channel][c] = struct{}{}
+	c.channels[channel] = struct{}{}
+
+	return nil
+}
+
+// unsubscribe removes c from channel
+func (h *Hub) unsubscribe(c *client, channel string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.removeLocked(c, channel)
+}
+
+// disconnect removes c from all its channels
+func (h *Hub) disconnect(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for channel := range c.channels {
+		h.removeLocked(c, channel)
+	}
+}
+
+func (h *Hub) removeLocked(c *client, channel string) {
+	delete(c.channels, channel)
+	delete(h.channels[channel], c)
+	if len(h.channels[channel]) == 0 {
+		delete(h.channels, channel)
+	}
+}
+
+// Subscribers returns the number of clients on this instance subscribed to channel
+func (h *Hub) Subscribers(channel string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.channels[channel])
+}
+
+func (h *Hub) logger() *slog.Logger {
+	if h.Logger == nil {
+		return slog.Default()
+	}
+	return h.Logger
+}
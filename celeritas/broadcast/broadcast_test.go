@@ -0,0 +1,198 @@
+package broadcast
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readSSE returns the next message on an SSE stream
+func readSSE(t *testing.T, r *bufio.Reader) Message {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, ok := strings.CutPrefix(strings.TrimSpace(line), "data: ")
+		if !ok {
+			continue
+		}
+
+		var msg Message
+		if err := json.Unmarshal([]byte(data), &msg); err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+}
+
+func TestHub_SSE(t *testing.T) {
+	hub := newTestHub(nil)
+	srv, client := testServer(t, hub)
+
+	resp, err := client.Get(srv.URL + "/broadcasting/sse?channel=orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Error("wrong content type:", resp.Header.Get("Content-Type"))
+	}
+
+	waitForSubscribers(t, hub, "orders", 1)
+
+	err = hub.Publish("orders", "created", map[string]int{"id": 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := readSSE(t, bufio.NewReader(resp.Body))
+	if msg.Channel != "orders" || msg.Event != "created" || string(msg.Data) != `{"id":42}` {
+		t.Error("wrong message:", msg.Channel, msg.Event, string(msg.Data))
+	}
+}
+
+var privateTests = []struct {
+	name    string
+	login   bool
+	channel string
+	status  int
+}{
+	{"public", false, "orders", http.StatusOK},
+	{"private-logged-out", false, "private-orders.7", http.StatusForbidden},
+	{"private-own", true, "private-orders.7", http.StatusOK},
+	{"private-other-user", true, "private-orders.8", http.StatusForbidden},
+	{"private-no-authorizer", true, "private-admin", http.StatusForbidden},
+}
+
+func TestHub_PrivateChannels(t *testing.T) {
+	for _, e := range privateTests {
+		hub := newTestHub(nil)
+		hub.Authorize("private-orders.*", func(r *http.Request, userID int, channel string) bool {
+			return channel == "private-orders.7" && userID == 7
+		})
+		srv, client := testServer(t, hub)
+
+		if e.login {
+			resp, err := client.Get(srv.URL + "/login")
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+		}
+
+		resp, err := client.Get(srv.URL + "/broadcasting/sse?channel=" + e.channel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != e.status {
+			t.Errorf("%s: expected status %d, got %d", e.name, e.status, resp.StatusCode)
+		}
+	}
+}
+
+func TestHub_WebSocket(t *testing.T) {
+	hub := newTestHub(nil)
+	srv, _ := testServer(t, hub)
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/broadcasting/ws"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var msg Message
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	_ = conn.WriteJSON(command{Action: "subscribe", Channel: "private-admin"})
+	_ = conn.ReadJSON(&msg)
+	if msg.Event != SubscriptionError {
+		t.Error("expected a subscription error for a private channel, got", msg.Event)
+	}
+
+	_ = conn.WriteJSON(command{Action: "subscribe", Channel: "orders"})
+	_ = conn.ReadJSON(&msg)
+	if msg.Event != Subscribed || msg.Channel != "orders" {
+		t.Error("expected a subscription to orders, got", msg.Event, msg.Channel)
+	}
+
+	_ = hub.Publish("orders", "shipped", "ok")
+	_ = conn.ReadJSON(&msg)
+	if msg.Event != "shipped" || string(msg.Data) != `"ok"` {
+		t.Error("wrong message:", msg.Event, string(msg.Data))
+	}
+
+	_ = conn.WriteJSON(command{Action: "unsubscribe", Channel: "orders"})
+	waitForSubscribers(t, hub, "orders", 0)
+}
+
+func TestHub_RedisFanOut(t *testing.T) {
+	// two instances of the application, sharing redis
+	publisher := newTestHub(testPool())
+	receiver := newTestHub(testPool())
+	publisher.Start()
+	receiver.Start()
+	defer publisher.Close()
+
+	srv, client := testServer(t, receiver)
+
+	resp, err := client.Get(srv.URL + "/broadcasting/sse?channel=orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	waitForSubscribers(t, receiver, "orders", 1)
+
+	// wait for the receiver's redis subscription
+	deadline := time.Now().Add(2 * time.Second)
+	for testRedis.PubSubNumSub("test-celeritas:broadcast")["test-celeritas:broadcast"] < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("hubs did not subscribe to redis")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	err = publisher.Publish("orders", "created", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := readSSE(t, bufio.NewReader(resp.Body))
+	if msg.Event != "created" {
+		t.Error("message not fanned out through redis:", msg.Event)
+	}
+}
+
+func TestHub_CloseDisconnects(t *testing.T) {
+	hub := newTestHub(nil)
+	srv, client := testServer(t, hub)
+
+	resp, err := client.Get(srv.URL + "/broadcasting/sse?channel=orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	waitForSubscribers(t, hub, "orders", 1)
+	hub.Close()
+	waitForSubscribers(t, hub, "orders", 0)
+}
+
+func TestHub_Script(t *testing.T) {
+	hub := newTestHub(nil)
+	script := string(hub.Script("abc123"))
+
+	if !strings.HasPrefix(script, `<script nonce="abc123">`) || !strings.Contains(script, `var base = "/broadcasting";`) {
+		t.Error("wrong client script:", script[:60])
+	}
+}
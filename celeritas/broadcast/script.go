@@ -0,0 +1,100 @@
+package broadcast
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+)
+
+// clientScript connects to the hub over a WebSocket, or over SSE in browsers without
+// WebSocket support, and reconnects when the connection drops. It is used as
+//
+//	Celeritas.subscribe("orders").on("created", function (data, message) { ... });
+const clientScript = `(function () {
+  var base = %s;
+  var handlers = {};
+  var socket = null, source = null, wait = 1000;
+
+  function dispatch(message) {
+    var events = handlers[message.channel] || {};
+    (events[message.event] || []).concat(events["*"] || []).forEach(function (fn) {
+      fn(message.data, message);
+    });
+  }
+
+  function send(action, channel) {
+    if (socket && socket.readyState === WebSocket.OPEN) {
+      socket.send(JSON.stringify({action: action, channel: channel}));
+    }
+  }
+
+  function connectSocket() {
+    var scheme = location.protocol === "https:" ? "wss://" : "ws://";
+    socket = new WebSocket(scheme + location.host + base + "/ws");
+    socket.onopen = function () {
+      wait = 1000;
+      Object.keys(handlers).forEach(function (channel) { send("subscribe", channel); });
+    };
+    socket.onmessage = function (e) { dispatch(JSON.parse(e.data)); };
+    socket.onclose = function () {
+      socket = null;
+      setTimeout(connectSocket, wait);
+      wait = Math.min(wait * 2, 30000);
+    };
+  }
+
+  function connectSource() {
+    if (source) {
+      source.close();
+    }
+    var query = Object.keys(handlers).map(function (channel) {
+      return "channel=" + encodeURIComponent(channel);
+    }).join("&");
+    if (!query) {
+      source = null;
+      return;
+    }
+    source = new EventSource(base + "/sse?" + query);
+    source.onmessage = function (e) { dispatch(JSON.parse(e.data)); };
+  }
+
+  function subscribe(channel) {
+    if (!handlers[channel]) {
+      handlers[channel] = {};
+      if (!window.WebSocket) {
+        connectSource();
+      } else if (!socket) {
+        connectSocket();
+      } else {
+        send("subscribe", channel);
+      }
+    }
+
+    return {
+      on: function (event, fn) {
+        (handlers[channel][event] = handlers[channel][event] || []).push(fn);
+        return this;
+      },
+      leave: function () {
+        delete handlers[channel];
+        if (window.WebSocket) {
+          send("unsubscribe", channel);
+        } else {
+          connectSource();
+        }
+      }
+    };
+  }
+
+  window.Celeritas = window.Celeritas || {};
+  window.Celeritas.subscribe = subscribe;
+})();`
+
+// Script returns the script tag with the client for the hub, allowed by the CSP nonce of the
+// request. In Jet templates it is available as {{ broadcastScript(cspNonce) | raw }}, and in
+// Go templates as {{ broadcastScript .CSPNonce }}
+func (h *Hub) Script(nonce string) template.HTML {
+	base, _ := json.Marshal(h.Path)
+	return template.HTML(fmt.Sprintf(`<script nonce="%s">`, template.HTMLEscapeString(nonce)) +
+		fmt.Sprintf(clientScript, base) + "</script>")
+}
@@ -0,0 +1,76 @@
+package broadcast
+
+import (
+	"github.com/alexedwards/scs/v2"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/gomodule/redigo/redis"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+var testRedis *miniredis.Miniredis
+
+func TestMain(m *testing.M) {
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	testRedis = s
+
+	code := m.Run()
+	s.Close()
+	os.Exit(code)
+}
+
+func testPool() *redis.Pool {
+	return &redis.Pool{
+		MaxIdle:     10,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", testRedis.Addr())
+		},
+	}
+}
+
+// testServer serves hub at /broadcasting, behind the session middleware, with /login logging
+// in as user 7
+func testServer(t *testing.T, hub *Hub) (*httptest.Server, *http.Client) {
+	mux := chi.NewRouter()
+	mux.Use(hub.Session.LoadAndSave)
+	mux.Get("/login", func(w http.ResponseWriter, r *http.Request) {
+		hub.Session.Put(r.Context(), "userID", 7)
+	})
+	mux.Mount("/broadcasting", hub.Routes())
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(func() {
+		hub.Close()
+		srv.Close()
+	})
+
+	jar, _ := cookiejar.New(nil)
+	return srv, &http.Client{Jar: jar}
+}
+
+func newTestHub(pool *redis.Pool) *Hub {
+	return New(testLogger, scs.New(), pool, "test-celeritas")
+}
+
+// waitForSubscribers waits until channel has n subscribers on hub
+func waitForSubscribers(t *testing.T, hub *Hub, channel string, n int) {
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.Subscribers(channel) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d subscribers on %s, got %d", n, channel, hub.Subscribers(channel))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,70 @@
+package broadcast
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ServeSSE streams the messages on the channels named in the channel query parameters, e.g.
+// /sse?channel=orders&channel=private-orders.42, as server-sent events
+func (h *Hub) ServeSSE(w http.ResponseWriter, r *http.Request) {
+	c := newClient()
+	defer h.disconnect(c)
+
+	for _, channel := range r.URL.Query()["channel"] {
+		err := h.subscribe(r, c, channel)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("%s: %s", err, channel), http.StatusForbidden)
+			return
+		}
+	}
+
+	// the stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // stop nginx from buffering the stream
+	w.WriteHeader(http.StatusOK)
+
+	// EventSource reconnects after retry milliseconds when the connection drops
+	_, err := fmt.Fprint(w, "retry: 3000\n\n")
+	if err != nil || rc.Flush() != nil {
+		return
+	}
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case msg := <-c.send:
+			data, err := json.Marshal(msg)
+			if err != nil {
+				h.logger().Error("could not encode broadcast message", "error", err)
+				continue
+			}
+			_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+			if err != nil {
+				return
+			}
+		case <-ping.C:
+			_, err := fmt.Fprint(w, ": ping\n\n")
+			if err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		}
+
+		if rc.Flush() != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package broadcast
+
+import (
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"time"
+)
+
+// command is sent by a WebSocket client to change its subscriptions
+type command struct {
+	Action  string `json:"action"` // subscribe or unsubscribe
+	Channel string `json:"channel"`
+}
+
+// upgrader only accepts connections from pages served by the application itself
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+const (
+	writeWait      = 10 * time.Second
+	maxCommandSize = 4096
+)
+
+// ServeWebSocket upgrades the connection to a WebSocket, and sends it the messages on the
+// channels it subscribes to. Channels can be named in channel query parameters, like for
+// ServeSSE, and changed later by sending {"action": "subscribe", "channel": "orders"} or
+// {"action": "unsubscribe", ...}. Every subscription is answered with a subscribed or
+// subscription_error message on the channel
+func (h *Hub) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has responded already
+		return
+	}
+	defer conn.Close()
+
+	c := newClient()
+	defer h.disconnect(c)
+
+	for _, channel := range r.URL.Query()["channel"] {
+		h.handleCommand(r, c, command{Action: "subscribe", Channel: channel})
+	}
+
+	// the browser's messages are read in the background, while this goroutine writes
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		h.readCommands(r, c, conn)
+	}()
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case msg := <-c.send:
+			_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ping.C:
+			err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+			if err != nil {
+				return
+			}
+		case <-readDone:
+			return
+		case <-h.done:
+			_ = conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+				time.Now().Add(writeWait))
+			return
+		}
+	}
+}
+
+// readCommands handles the subscription commands sent by the browser until the connection closes
+func (h *Hub) readCommands(r *http.Request, c *client, conn *websocket.Conn) {
+	conn.SetReadLimit(maxCommandSize)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * pingInterval))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * pingInterval))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(2 * pingInterval))
+
+		var cmd command
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			continue
+		}
+		h.handleCommand(r, c, cmd)
+	}
+}
+
+// handleCommand changes the subscriptions of c, and queues the reply to the browser
+func (h *Hub) handleCommand(r *http.Request, c *client, cmd command) {
+	switch cmd.Action {
+	case "subscribe":
+		event := Subscribed
+		if err := h.subscribe(r, c, cmd.Channel); err != nil {
+			event = SubscriptionError
+		}
+		h.reply(c, Message{Channel: cmd.Channel, Event: event})
+	case "unsubscribe":
+		h.unsubscribe(c, cmd.Channel)
+	}
+}
+
+func (h *Hub) reply(c *client, msg Message) {
+	select {
+	case c.send <- msg:
+	default:
+	}
+}
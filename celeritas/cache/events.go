@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/fouched/celeritas/events"
+	"github.com/gomodule/redigo/redis"
+	"time"
+)
+
+// Hit is published when Get finds a key in the cache
+type Hit struct {
+	Key string
+}
+
+func (Hit) Name() string { return "cache.hit" }
+
+// Miss is published when Get does not find a key in the cache
+type Miss struct {
+	Key string
+}
+
+func (Miss) Name() string { return "cache.miss" }
+
+// Written is published when a key is stored in the cache
+type Written struct {
+	Key string
+}
+
+func (Written) Name() string { return "cache.written" }
+
+// Forgotten is published when a key is removed from the cache
+type Forgotten struct {
+	Key string
+}
+
+func (Forgotten) Name() string { return "cache.forgotten" }
+
+// Emptied is published when all keys, or all keys starting with Pattern, are removed from the cache
+type Emptied struct {
+	Pattern string
+}
+
+func (Emptied) Name() string { return "cache.emptied" }
+
+// eventCache publishes an event for every successful operation on the cache it wraps
+type eventCache struct {
+	Cache
+	ctx    context.Context
+	events *events.Bus
+}
+
+// WithEvents wraps c, so that every operation on it publishes an event to bus
+func WithEvents(c Cache, bus *events.Bus) Cache {
+	return &eventCache{Cache: c, ctx: context.Background(), events: bus}
+}
+
+// WithContext binds the wrapped cache to ctx, and publishes the events with it
+func (c *eventCache) WithContext(ctx context.Context) Cache {
+	return &eventCache{Cache: WithContext(ctx, c.Cache), ctx: ctx, events: c.events}
+}
+
+func (c *eventCache) Get(key string) (interface{}, error) {
+	value, err := c.Cache.Get(key)
+	switch {
+	case err == nil:
+		c.events.Publish(c.ctx, Hit{Key: key})
+	case isMiss(err):
+		c.events.Publish(c.ctx, Miss{Key: key})
+	}
+
+	return value, err
+}
+
+// isMiss reports whether err means that the key is not in the cache
+func isMiss(err error) bool {
+	return errors.Is(err, redis.ErrNil) || errors.Is(err, badger.ErrKeyNotFound) || errors.Is(err, ErrNotFound)
+}
+
+func (c *eventCache) Set(key string, value interface{}, expires ...int) error {
+	err := c.Cache.Set(key, value, expires...)
+	if err == nil {
+		c.events.Publish(c.ctx, Written{Key: key})
+	}
+
+	return err
+}
+
+func (c *eventCache) Forget(key string) error {
+	err := c.Cache.Forget(key)
+	if err == nil {
+		c.events.Publish(c.ctx, Forgotten{Key: key})
+	}
+
+	return err
+}
+
+func (c *eventCache) EmptyByMatch(pattern string) error {
+	err := c.Cache.EmptyByMatch(pattern)
+	if err == nil {
+		c.events.Publish(c.ctx, Emptied{Pattern: pattern})
+	}
+
+	return err
+}
+
+func (c *eventCache) Empty() error {
+	err := c.Cache.Empty()
+	if err == nil {
+		c.events.Publish(c.ctx, Emptied{})
+	}
+
+	return err
+}
+
+// Lock forwards to the wrapped cache, so the scheduler can still use it for tasks that run on
+// one server
+func (c *eventCache) Lock(key string, ttl time.Duration) (string, bool, error) {
+	locker, ok := c.Cache.(Locker)
+	if !ok {
+		return "", false, errors.New("cache does not support locks")
+	}
+	return locker.Lock(key, ttl)
+}
+
+func (c *eventCache) Unlock(key, token string) error {
+	locker, ok := c.Cache.(Locker)
+	if !ok {
+		return errors.New("cache does not support locks")
+	}
+	return locker.Unlock(key, token)
+}
+
+// Update forwards to the wrapped cache, so it can still be used for rate limits
+func (c *eventCache) Update(key string, ttl time.Duration, fn func(value []byte) ([]byte, error)) error {
+	updater, ok := c.Cache.(Updater)
+	if !ok {
+		return errors.New("cache does not support atomic updates")
+	}
+	return updater.Update(key, ttl, fn)
+}
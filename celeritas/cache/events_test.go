@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"github.com/fouched/celeritas/events"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestWithEvents(t *testing.T) {
+	for _, e := range lockTests {
+		bus := events.New(slog.New(slog.NewTextHandler(io.Discard, nil)), 1, 1)
+
+		var got []string
+		bus.Listen(events.All, func(ctx context.Context, ev events.Event) error {
+			got = append(got, ev.Name())
+			return nil
+		})
+
+		c := WithEvents(e.locker.(Cache), bus)
+
+		_ = c.Set("events-foo", "bar")
+		_, _ = c.Get("events-foo")
+		_ = c.Forget("events-foo")
+		_, _ = c.Get("events-foo")
+		_ = c.EmptyByMatch("events-")
+
+		want := []string{"cache.written", "cache.hit", "cache.forgotten", "cache.miss", "cache.emptied"}
+		if len(got) != len(want) {
+			t.Fatal(e.name, "wrong events:", got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Error(e.name, "expected", want[i], "got", got[i])
+			}
+		}
+
+		if _, ok := c.(Locker); !ok {
+			t.Error(e.name, "cache with events is not a Locker")
+		}
+
+		_ = bus.Close(context.Background())
+	}
+}
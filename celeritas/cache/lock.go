@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gomodule/redigo/redis"
+	"time"
+)
+
+// Locker is implemented by caches that can hold a lock, which is shared by every process
+// using the same cache. Lock returns false if the lock is already held, and otherwise a
+// token that must be passed to Unlock. Locks expire after ttl, so a crashed process cannot
+// hold a lock forever
+type Locker interface {
+	Lock(key string, ttl time.Duration) (string, bool, error)
+	Unlock(key, token string) error
+}
+
+var errLocked = errors.New("locked")
+
+// unlockScript deletes a lock only if it is still held with the given token, so a process
+// never releases a lock that expired and was acquired by another process in the meantime
+var unlockScript = redis.NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+func lockToken() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Lock acquires the lock key for ttl, unless it is already held
+func (c *RedisCache) Lock(key string, ttl time.Duration) (string, bool, error) {
+	key = fmt.Sprintf("%s:lock:%s", c.Prefix, key)
+	conn := c.Conn.Get()
+	defer conn.Close()
+
+	token, err := lockToken()
+	if err != nil {
+		return "", false, err
+	}
+
+	_, err = redis.String(conn.Do("SET", key, token, "NX", "PX", ttl.Milliseconds()))
+	if errors.Is(err, redis.ErrNil) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+
+	return token, true, nil
+}
+
+// Unlock releases the lock key, if it is still held with token
+func (c *RedisCache) Unlock(key, token string) error {
+	key = fmt.Sprintf("%s:lock:%s", c.Prefix, key)
+	conn := c.Conn.Get()
+	defer conn.Close()
+
+	_, err := unlockScript.Do(conn, key, token)
+	return err
+}
+
+// Lock acquires the lock key for ttl, unless it is already held. Badger is not shared between
+// processes, so the lock only guards against other goroutines
+func (b *BadgerCache) Lock(key string, ttl time.Duration) (string, bool, error) {
+	key = "lock:" + key
+	token, err := lockToken()
+	if err != nil {
+		return "", false, err
+	}
+
+	err = b.Conn.Update(func(txn *badger.Txn) error {
+		_, err := txn.Get([]byte(key))
+		if err == nil {
+			return errLocked
+		} else if !errors.Is(err, badger.ErrKeyNotFound) {
+			return err
+		}
+
+		return txn.SetEntry(badger.NewEntry([]byte(key), []byte(token)).WithTTL(ttl))
+	})
+	if errors.Is(err, errLocked) || errors.Is(err, badger.ErrConflict) {
+		// a conflict means another goroutine acquired the lock at the same time
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+
+	return token, true, nil
+}
+
+// Unlock releases the lock key, if it is still held with token
+func (b *BadgerCache) Unlock(key, token string) error {
+	key = "lock:" + key
+
+	return b.Conn.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		held, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+
+		if string(held) != token {
+			return nil
+		}
+
+		return txn.Delete([]byte(key))
+	})
+}
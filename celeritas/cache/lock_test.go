@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+var lockTests = []struct {
+	name   string
+	locker Locker
+}{
+	{"redis", &testRedisCache},
+	{"badger", &testBadgerCache},
+}
+
+func TestLocker(t *testing.T) {
+	for _, e := range lockTests {
+		token, ok, err := e.locker.Lock("task", time.Minute)
+		if err != nil {
+			t.Fatal(e.name, err)
+		}
+
+		if !ok {
+			t.Error(e.name, "could not acquire a free lock")
+		}
+
+		_, ok, _ = e.locker.Lock("task", time.Minute)
+		if ok {
+			t.Error(e.name, "acquired a lock that is already held")
+		}
+
+		// releasing with the wrong token leaves the lock alone
+		_ = e.locker.Unlock("task", "not-the-token")
+		_, ok, _ = e.locker.Lock("task", time.Minute)
+		if ok {
+			t.Error(e.name, "lock released with the wrong token")
+		}
+
+		err = e.locker.Unlock("task", token)
+		if err != nil {
+			t.Error(e.name, err)
+		}
+
+		token, ok, _ = e.locker.Lock("task", time.Minute)
+		if !ok {
+			t.Error(e.name, "could not acquire a released lock")
+		}
+		_ = e.locker.Unlock("task", token)
+	}
+}
@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by MemoryCache when a key does not exist
+var ErrNotFound = errors.New("cache: key not found")
+
+// MemoryCache keeps entries in the memory of the process. It is not shared between processes,
+// and is used where the application needs a cache but none is configured, e.g. for rate limits
+type MemoryCache struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+}
+
+type memoryEntry struct {
+	value   interface{}
+	expires time.Time // zero if the entry does not expire
+}
+
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+// sweepInterval is how often expired entries are removed
+const sweepInterval = time.Minute
+
+// NewMemoryCache creates an empty memory cache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		entries:   make(map[string]memoryEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+// get returns the entry for key, if it exists and has not expired. The caller must hold the lock
+func (m *MemoryCache) get(key string, now time.Time) (memoryEntry, bool) {
+	e, ok := m.entries[key]
+	if !ok {
+		return memoryEntry{}, false
+	}
+
+	if e.expired(now) {
+		delete(m.entries, key)
+		return memoryEntry{}, false
+	}
+
+	return e, true
+}
+
+// set stores an entry, and removes expired entries now and then. The caller must hold the lock
+func (m *MemoryCache) set(key string, e memoryEntry, now time.Time) {
+	m.entries[key] = e
+
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+
+	for k, e := range m.entries {
+		if e.expired(now) {
+			delete(m.entries, k)
+		}
+	}
+	m.lastSweep = now
+}
+
+func (m *MemoryCache) Has(key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.get(key, time.Now())
+	return ok, nil
+}
+
+func (m *MemoryCache) Get(key string) (interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.get(key, time.Now())
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return e.value, nil
+}
+
+// Set creates an entry in the cache with an optional expiry time in seconds
+func (m *MemoryCache) Set(key string, value interface{}, expires ...int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	e := memoryEntry{value: value}
+	if len(expires) > 0 {
+		e.expires = now.Add(time.Duration(expires[0]) * time.Second)
+	}
+	m.set(key, e, now)
+
+	return nil
+}
+
+func (m *MemoryCache) Forget(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *MemoryCache) EmptyByMatch(prefix string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for k := range m.entries {
+		if strings.HasPrefix(k, prefix) {
+			delete(m.entries, k)
+		}
+	}
+
+	return nil
+}
+
+func (m *MemoryCache) Empty() error {
+	return m.EmptyByMatch("")
+}
+
+// Update changes the value of key atomically
+func (m *MemoryCache) Update(key string, ttl time.Duration, fn func(value []byte) ([]byte, error)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var value []byte
+	if e, ok := m.get(key, now); ok {
+		value, _ = e.value.([]byte)
+	}
+
+	value, err := fn(value)
+	if err != nil {
+		return err
+	}
+
+	m.set(key, memoryEntry{value: value, expires: now.Add(ttl)}, now)
+	return nil
+}
+
+// Lock acquires the lock key for ttl, unless it is already held. The lock only guards against
+// other goroutines
+func (m *MemoryCache) Lock(key string, ttl time.Duration) (string, bool, error) {
+	key = "lock:" + key
+
+	token, err := lockToken()
+	if err != nil {
+		return "", false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if _, ok := m.get(key, now); ok {
+		return "", false, nil
+	}
+	m.set(key, memoryEntry{value: token, expires: now.Add(ttl)}, now)
+
+	return token, true, nil
+}
+
+// Unlock releases the lock key, if it is still held with token
+func (m *MemoryCache) Unlock(key, token string) error {
+	key = "lock:" + key
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.get(key, time.Now()); ok && e.value == token {
+		delete(m.entries, key)
+	}
+
+	return nil
+}
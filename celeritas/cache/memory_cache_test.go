@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache(t *testing.T) {
+	m := NewMemoryCache()
+
+	_ = m.Set("foo", "bar")
+	_ = m.Set("alpha", 1)
+	_ = m.Set("expired", "gone", -1)
+
+	value, err := m.Get("foo")
+	if err != nil || value != "bar" {
+		t.Error("wrong value for foo:", value, err)
+	}
+
+	if _, err := m.Get("expired"); !errors.Is(err, ErrNotFound) {
+		t.Error("expected an expired entry to be gone, got", err)
+	}
+
+	_ = m.EmptyByMatch("al")
+	if ok, _ := m.Has("alpha"); ok {
+		t.Error("alpha not emptied by match")
+	}
+	if ok, _ := m.Has("foo"); !ok {
+		t.Error("foo emptied by a match for another prefix")
+	}
+
+	token, ok, _ := m.Lock("task", time.Minute)
+	if _, again, _ := m.Lock("task", time.Minute); !ok || again {
+		t.Error("memory lock not exclusive")
+	}
+	_ = m.Unlock("task", token)
+	if _, ok, _ := m.Lock("task", time.Minute); !ok {
+		t.Error("memory lock not released")
+	}
+}
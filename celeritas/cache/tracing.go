@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+// ContextCache is implemented by caches that can be bound to a context, e.g. so that the spans
+// they record belong to the trace of the request that uses them
+type ContextCache interface {
+	WithContext(ctx context.Context) Cache
+}
+
+// WithContext returns c bound to ctx, or c itself if it cannot be bound, e.g.
+//
+//	value, err := cache.WithContext(r.Context(), app.Cache).Get("key")
+func WithContext(ctx context.Context, c Cache) Cache {
+	if cc, ok := c.(ContextCache); ok {
+		return cc.WithContext(ctx)
+	}
+	return c
+}
+
+// tracingCache records a span for every operation on the cache it wraps
+type tracingCache struct {
+	Cache
+	ctx    context.Context
+	tracer trace.Tracer
+}
+
+// WithTracing wraps c, so that every operation on it records a span with the global tracer
+// provider. Operations on a cache that is not bound to a context with WithContext start a
+// new trace
+func WithTracing(c Cache) Cache {
+	return &tracingCache{
+		Cache:  c,
+		ctx:    context.Background(),
+		tracer: otel.Tracer("github.com/fouched/celeritas/cache"),
+	}
+}
+
+func (c *tracingCache) WithContext(ctx context.Context) Cache {
+	return &tracingCache{Cache: WithContext(ctx, c.Cache), ctx: ctx, tracer: c.tracer}
+}
+
+// trace runs op in a span called "cache operation", recording its error, if any. A key that
+// is not in the cache is not an error
+func (c *tracingCache) trace(operation, key string, op func() error) error {
+	_, span := c.tracer.Start(c.ctx, "cache "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("cache.operation", operation), attribute.String("cache.key", key)),
+	)
+	defer span.End()
+
+	err := op()
+	if err != nil && !isMiss(err) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
+}
+
+func (c *tracingCache) Has(key string) (bool, error) {
+	var found bool
+	err := c.trace("has", key, func() (err error) {
+		found, err = c.Cache.Has(key)
+		return err
+	})
+
+	return found, err
+}
+
+func (c *tracingCache) Get(key string) (interface{}, error) {
+	var value interface{}
+	err := c.trace("get", key, func() (err error) {
+		value, err = c.Cache.Get(key)
+		return err
+	})
+
+	return value, err
+}
+
+func (c *tracingCache) Set(key string, value interface{}, expires ...int) error {
+	return c.trace("set", key, func() error {
+		return c.Cache.Set(key, value, expires...)
+	})
+}
+
+func (c *tracingCache) Forget(key string) error {
+	return c.trace("forget", key, func() error {
+		return c.Cache.Forget(key)
+	})
+}
+
+func (c *tracingCache) EmptyByMatch(pattern string) error {
+	return c.trace("empty", pattern, func() error {
+		return c.Cache.EmptyByMatch(pattern)
+	})
+}
+
+func (c *tracingCache) Empty() error {
+	return c.trace("empty", "", func() error {
+		return c.Cache.Empty()
+	})
+}
+
+// Lock, Unlock and Update forward to the wrapped cache, so the scheduler and rate limits can
+// still use it
+func (c *tracingCache) Lock(key string, ttl time.Duration) (string, bool, error) {
+	locker, ok := c.Cache.(Locker)
+	if !ok {
+		return "", false, errors.New("cache does not support locks")
+	}
+	return locker.Lock(key, ttl)
+}
+
+func (c *tracingCache) Unlock(key, token string) error {
+	locker, ok := c.Cache.(Locker)
+	if !ok {
+		return errors.New("cache does not support locks")
+	}
+	return locker.Unlock(key, token)
+}
+
+func (c *tracingCache) Update(key string, ttl time.Duration, fn func(value []byte) ([]byte, error)) error {
+	updater, ok := c.Cache.(Updater)
+	if !ok {
+		return errors.New("cache does not support atomic updates")
+	}
+
+	return c.trace("update", key, func() error {
+		return updater.Update(key, ttl, fn)
+	})
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func TestWithTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+
+	c := WithEvents(WithTracing(NewMemoryCache()), nil)
+	bound := WithContext(ctx, c)
+
+	_ = bound.Set("tracing-foo", "bar")
+	_, _ = bound.Get("tracing-foo")
+	_, _ = c.Get("tracing-missing")
+	parent.End()
+
+	if _, ok := bound.(Updater); !ok {
+		t.Error("cache with tracing is not an Updater")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatal("expected 4 spans, got", len(spans))
+	}
+
+	for i, name := range []string{"cache set", "cache get"} {
+		if spans[i].Name() != name {
+			t.Errorf("expected span %s, got %s", name, spans[i].Name())
+		}
+		if spans[i].Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Error(name, "is not part of the request trace")
+		}
+	}
+
+	if spans[2].Parent().IsValid() {
+		t.Error("expected an unbound cache to start a new trace")
+	}
+	if len(spans[2].Events()) > 0 {
+		t.Error("expected a miss not to be recorded as an error")
+	}
+}
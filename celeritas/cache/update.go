@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gomodule/redigo/redis"
+	"time"
+)
+
+// Updater is implemented by caches that can change a value atomically, e.g. a counter that is
+// shared by every process using the same cache. Update calls fn with the current value of
+// key, or nil if it does not exist, and stores the value fn returns for ttl. When another
+// process changes the value at the same time, fn is called again with the new value. Values
+// are stored as they are, so they cannot be read with Get
+type Updater interface {
+	Update(key string, ttl time.Duration, fn func(value []byte) ([]byte, error)) error
+}
+
+// ErrUpdateConflict is returned when a value keeps changing while Update tries to change it
+var ErrUpdateConflict = errors.New("cache: too many concurrent updates")
+
+// updateAttempts is how often Update retries when the value changed while fn was running
+const updateAttempts = 10
+
+// Update changes the value of key atomically, using an optimistic transaction
+func (c *RedisCache) Update(key string, ttl time.Duration, fn func(value []byte) ([]byte, error)) error {
+	key = fmt.Sprintf("%s:%s", c.Prefix, key)
+	conn := c.Conn.Get()
+	defer conn.Close()
+
+	for i := 0; i < updateAttempts; i++ {
+		_, err := conn.Do("WATCH", key)
+		if err != nil {
+			return err
+		}
+
+		value, err := redis.Bytes(conn.Do("GET", key))
+		if err != nil && !errors.Is(err, redis.ErrNil) {
+			_, _ = conn.Do("UNWATCH")
+			return err
+		}
+
+		value, err = fn(value)
+		if err != nil {
+			_, _ = conn.Do("UNWATCH")
+			return err
+		}
+
+		_ = conn.Send("MULTI")
+		_ = conn.Send("SET", key, value, "PX", max(ttl.Milliseconds(), 1))
+		reply, err := conn.Do("EXEC")
+		if err != nil {
+			return err
+		}
+
+		if reply != nil {
+			return nil
+		}
+		// the transaction was aborted because the value changed, so try again
+	}
+
+	return ErrUpdateConflict
+}
+
+// Update changes the value of key atomically, in a badger transaction
+func (b *BadgerCache) Update(key string, ttl time.Duration, fn func(value []byte) ([]byte, error)) error {
+	for i := 0; i < updateAttempts; i++ {
+		err := b.Conn.Update(func(txn *badger.Txn) error {
+			var value []byte
+			item, err := txn.Get([]byte(key))
+			if err == nil {
+				value, err = item.ValueCopy(nil)
+				if err != nil {
+					return err
+				}
+			} else if !errors.Is(err, badger.ErrKeyNotFound) {
+				return err
+			}
+
+			value, err = fn(value)
+			if err != nil {
+				return err
+			}
+
+			return txn.SetEntry(badger.NewEntry([]byte(key), value).WithTTL(ttl))
+		})
+		if !errors.Is(err, badger.ErrConflict) {
+			return err
+		}
+	}
+
+	return ErrUpdateConflict
+}
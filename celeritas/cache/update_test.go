@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+var updateTests = []struct {
+	name    string
+	updater Updater
+}{
+	{"redis", &testRedisCache},
+	{"badger", &testBadgerCache},
+	{"memory", NewMemoryCache()},
+}
+
+func TestUpdater(t *testing.T) {
+	for _, e := range updateTests {
+		increment := func(value []byte) ([]byte, error) {
+			n, _ := strconv.Atoi(string(value))
+			return []byte(strconv.Itoa(n + 1)), nil
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					err := e.updater.Update("counter", time.Minute, increment)
+					if err != ErrUpdateConflict {
+						if err != nil {
+							t.Error(e.name, err)
+						}
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		var got []byte
+		_ = e.updater.Update("counter", time.Minute, func(value []byte) ([]byte, error) {
+			got = value
+			return value, nil
+		})
+
+		if string(got) != "20" {
+			t.Errorf("%s: expected 20 concurrent increments, got %s", e.name, got)
+		}
+	}
+}
@@ -1,22 +1,40 @@
 package celeritas
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/CloudyKit/jet/v6"
 	"github.com/alexedwards/scs/v2"
 	"github.com/dgraph-io/badger/v4"
+	"github.com/fouched/celeritas/broadcast"
 	"github.com/fouched/celeritas/cache"
+	"github.com/fouched/celeritas/events"
+	"github.com/fouched/celeritas/features"
+	"github.com/fouched/celeritas/filesystem"
+	"github.com/fouched/celeritas/i18n"
+	"github.com/fouched/celeritas/jobs"
+	"github.com/fouched/celeritas/logger"
 	"github.com/fouched/celeritas/mailer"
+	"github.com/fouched/celeritas/metrics"
 	"github.com/fouched/celeritas/render"
+	"github.com/fouched/celeritas/scheduler"
 	"github.com/fouched/celeritas/session"
+	"github.com/fouched/celeritas/tracing"
 	"github.com/go-chi/chi/v5"
 	"github.com/gomodule/redigo/redis"
 	"github.com/joho/godotenv"
+	"io"
+	"io/fs"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strconv"
-	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
@@ -26,24 +44,54 @@ var redisCache *cache.RedisCache
 var redisPool *redis.Pool
 var badgerCache *cache.BadgerCache
 var badgerConn *badger.DB
+var badgerJobsConn *badger.DB
 
 type Celeritas struct {
-	AppName       string
-	Debug         bool
-	Version       string
-	ErrorLog      *log.Logger
-	InfoLog       *log.Logger
-	RootPath      string
-	Routes        *chi.Mux
-	Render        *render.Render
-	Session       *scs.SessionManager
-	DB            Database
-	JetViews      *jet.Set
-	config        config // no reason to export this
-	EncryptionKey string
-	Cache         cache.Cache
-	Mail          mailer.Mail
-	Server        Server
+	AppName        string
+	Debug          bool
+	Version        string
+	ErrorLog       *log.Logger
+	InfoLog        *log.Logger
+	Logger         *slog.Logger
+	RootPath       string
+	Routes         *chi.Mux
+	Render         *render.Render
+	Session        *scs.SessionManager
+	DB             Database
+	JetViews       *jet.Set
+	config         Config // no reason to export this
+	EncryptionKey  string
+	Cache          cache.Cache
+	Mail           mailer.Mail
+	Events         *events.Bus
+	Broadcast      *broadcast.Hub
+	Features       *features.Manager
+	Files          fs.FS // views, mail, lang and public, e.g. an embed.FS; set it before calling New
+	I18n           *i18n.Bundle
+	Disks          *filesystem.Disks
+	Metrics        *metrics.Metrics
+	Tracing        *tracing.Tracing // nil when tracing is disabled
+	Scheduler      *scheduler.Scheduler
+	Jobs           *jobs.Queue
+	Server         Server
+	server         *http.Server
+	redirectServer *http.Server
+	shutdownHooks  []ShutdownHook
+	shutdownOnce   sync.Once
+	shutdownErr    error
+	healthChecks   []namedHealthCheck
+	healthMu       sync.RWMutex
+	memoryCache    *cache.MemoryCache // rate limit counters when there is no cache
+	maintenance    maintenanceState
+	logCloser      io.Closer
+	stopWorkers    context.CancelFunc
+	workersDone    chan struct{}
+	providers      []Provider
+	bootOnce       sync.Once
+	bootErr        error
+	commands       map[string]Command
+
+	providersRegistered bool
 }
 
 type Server struct {
@@ -53,149 +101,186 @@ type Server struct {
 	URL        string
 }
 
-type config struct {
-	port        string
-	renderer    string
-	cookie      cookieConfig
-	sessionType string
-	database    databaseConfig
-	redis       redisConfig
-}
-
+// New reads the configuration from the .env file in rootPath and the environment, and
+// initialises Celeritas with it
 func (c *Celeritas) New(rootPath string) error {
-	pathConfig := initPaths{
-		rootPath:    rootPath,
-		folderNames: []string{"handlers", "migrations", "views", "mail", "data", "public", "tmp", "logs", "middleware"},
+	err := c.checkDotEnv(rootPath)
+	if err != nil {
+		return err
 	}
 
-	err := c.Init(pathConfig)
+	// read .env
+	err = godotenv.Load(rootPath + "/.env")
 	if err != nil {
 		return err
 	}
 
-	err = c.checkDotEnv(rootPath)
+	cfg, err := ReadConfig(environment())
 	if err != nil {
-		return err
+		// report conversion and validation problems together
+		return fmt.Errorf("invalid configuration: %w", errors.Join(err, cfg.Validate()))
 	}
 
-	// read .env
-	err = godotenv.Load(rootPath + "/.env")
+	return c.NewWithConfig(rootPath, cfg)
+}
+
+// NewWithConfig initialises Celeritas from a Config value, without reading .env or the environment
+func (c *Celeritas) NewWithConfig(rootPath string, cfg Config) error {
+	cfg.applyDefaults()
+	err := cfg.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	cfg.ResolvePaths(rootPath)
+
+	pathConfig := initPaths{
+		rootPath:    rootPath,
+		folderNames: []string{"handlers", "migrations", "views", "mail", "data", "public", "tmp", "logs", "middleware", "lang"},
+	}
+
+	err = c.Init(pathConfig)
 	if err != nil {
 		return err
 	}
 
+	// set Celeritas configuration
+	c.config = cfg
+
 	// create loggers
-	infoLog, errorLog := c.startLoggers()
+	infoLog, errorLog, err := c.startLoggers(rootPath)
+	if err != nil {
+		return err
+	}
 
-	// set Celeritas configuration
 	c.InfoLog = infoLog
 	c.ErrorLog = errorLog
-	c.Debug, _ = strconv.ParseBool(os.Getenv("DEBUG")) // in production or not
+	c.Debug = cfg.Debug // in production or not
 	c.Version = version
 	c.RootPath = rootPath
+
+	c.Tracing, err = c.createTracing()
+	if err != nil {
+		return err
+	}
+
+	c.Events = events.New(c.Logger, cfg.Events.Workers, cfg.Events.QueueSize)
+	c.Metrics = c.createMetrics()
 	c.Mail = c.createMailer()
 	c.Routes = c.routes().(*chi.Mux)
 
 	// connect to database if specified
-	if os.Getenv("DATABASE_TYPE") != "" {
-		db, err := c.OpenDB(os.Getenv("DATABASE_TYPE"), c.BuildDSN())
-		//TODO: build in retry
+	if cfg.Database.Type == "sqlite" {
+		// sqlite creates the database file, but not the folder it lives in
+		err = os.MkdirAll(filepath.Dir(cfg.Database.Name), 0755)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cfg.Database.Type != "" {
+		db, err := c.OpenDB(cfg.Database.Type, c.BuildDSN())
 		if err != nil {
-			errorLog.Println(err)
-			os.Exit(1)
+			return err
 		}
 		c.DB = Database{
-			Type: os.Getenv("DATABASE_TYPE"),
+			Type: cfg.Database.Type,
 			Pool: db,
 		}
+		c.Metrics.CollectDB(db, cfg.Database.Type)
 	}
 
-	if os.Getenv("CACHE") == "redis" || os.Getenv("SESSION_TYPE") == "redis" {
+	if cfg.Cache == "redis" || cfg.SessionType == "redis" {
 		redisCache = c.createRedisCache()
 		c.Cache = redisCache
 		redisPool = redisCache.Conn
 	}
 
-	if os.Getenv("CACHE") == "badger" {
+	if cfg.Cache == "badger" {
 		badgerCache = c.createBadgerCache()
 		c.Cache = badgerCache
 		badgerConn = badgerCache.Conn
+	}
 
-		go func() {
-			for range time.Tick(12 * time.Hour) {
-				_ = badgerCache.Conn.RunValueLogGC(0.7)
-			}
-		}()
+	if c.Cache != nil && c.Tracing != nil {
+		c.Cache = cache.WithTracing(c.Cache)
 	}
 
-	// setup config
-	c.config = config{
-		port:     os.Getenv("PORT"),
-		renderer: os.Getenv("RENDERER"),
-		cookie: cookieConfig{
-			name:     os.Getenv("COOKIE_NAME"),
-			lifetime: os.Getenv("COOKIE_LIFETIME"),
-			persist:  os.Getenv("COOKIE_PERSIST"),
-			secure:   os.Getenv("COOKIE_SECURE"),
-			domain:   os.Getenv("COOKIE_DOMAIN"),
-		},
-		sessionType: os.Getenv("SESSION_TYPE"),
-		database: databaseConfig{
-			dsn:      c.BuildDSN(),
-			database: os.Getenv("DATABASE_TYPE"),
-		},
-		redis: redisConfig{
-			host:     os.Getenv("REDIS_HOST"),
-			password: os.Getenv("REDIS_PASSWORD"),
-			prefix:   os.Getenv("REDIS_PREFIX"),
-		},
+	if c.Cache != nil {
+		c.Cache = cache.WithEvents(c.Cache, c.Events)
 	}
 
-	secure := true
-	if strings.ToLower(os.Getenv("SECURE")) == "false" {
-		secure = false
+	if cfg.Jobs.Backend != "" {
+		c.Jobs = c.createJobQueue()
+		c.Metrics.CollectQueueSize(cfg.Jobs.Queues, c.Jobs.Size)
 	}
+
+	c.Scheduler = c.createScheduler()
+
 	c.Server = Server{
-		ServerName: os.Getenv("SERVER_NAME"),
-		Port:       os.Getenv("PORT"),
-		Secure:     secure,
-		URL:        os.Getenv("APP_URL"),
+		ServerName: cfg.ServerName,
+		Port:       cfg.Port,
+		Secure:     cfg.Secure,
+		URL:        cfg.AppURL,
 	}
 
 	// create session
 	s := session.Session{
-		CookieLifetime: c.config.cookie.lifetime,
-		CookiePersist:  c.config.cookie.persist,
-		CookieSecure:   c.config.cookie.secure,
-		CookieName:     c.config.cookie.name,
-		CookieDomain:   c.config.cookie.domain,
-		SessionType:    c.config.sessionType,
+		CookieLifetime: strconv.Itoa(cfg.Cookie.Lifetime),
+		CookiePersist:  strconv.FormatBool(cfg.Cookie.Persist),
+		CookieSecure:   strconv.FormatBool(cfg.Cookie.Secure),
+		CookieName:     cfg.Cookie.Name,
+		CookieDomain:   cfg.Cookie.Domain,
+		SessionType:    cfg.SessionType,
+		Events:         c.Events,
 	}
 
-	switch c.config.sessionType {
+	switch cfg.SessionType {
 	case "redis":
 		s.RedisPool = redisCache.Conn
-	case "mysql", "mariadb", "postgres", "postgresql":
+	case "mysql", "mariadb", "postgres", "postgresql", "sqlite":
 		s.DBPool = c.DB.Pool
 	}
 
 	c.Session = s.InitSession()
-	c.EncryptionKey = os.Getenv("KEY")
+	c.EncryptionKey = cfg.Key
+	c.Broadcast = c.createBroadcaster()
+	c.Features = c.createFeatures()
+
+	c.I18n, err = c.createI18n()
+	if err != nil {
+		return err
+	}
+
+	c.Disks, err = c.createDisks()
+	if err != nil {
+		return err
+	}
 
 	if c.Debug {
 		var views = jet.NewSet(
-			jet.NewOSFileSystemLoader(fmt.Sprintf("%s/views", rootPath)),
+			render.NewFSLoader(c.files("views")),
 			jet.InDevelopmentMode(),
 		)
 		c.JetViews = views
 	} else {
 		var views = jet.NewSet(
-			jet.NewOSFileSystemLoader(fmt.Sprintf("%s/views", rootPath)),
+			render.NewFSLoader(c.files("views")),
 		)
 		c.JetViews = views
 	}
 
 	c.createRenderer()
+	c.AddTemplateFunc("broadcastScript", c.Broadcast.Script)
+	c.Render.AddRequestFunc("feature", c.featureFunc)
+	c.Render.AddRequestFunc("t", c.I18n.TemplateFunc)
+	c.addDefaultHealthChecks()
+	c.addDefaultCommands()
+
+	err = c.registerProviders()
+	if err != nil {
+		return err
+	}
+
 	go c.Mail.ListenForMail()
 
 	return nil
@@ -214,10 +299,27 @@ func (c *Celeritas) Init(p initPaths) error {
 	return nil
 }
 
-// ListenAndServe starts the web server
-func (c *Celeritas) ListenAndServe() {
-	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%s", os.Getenv("PORT")),
+// ListenAndServe starts the web server and blocks until the process receives SIGINT or SIGTERM,
+// after which the application is shut down gracefully
+func (c *Celeritas) ListenAndServe() error {
+	err := c.Boot()
+	if err != nil {
+		return err
+	}
+
+	err = c.Scheduler.Start()
+	if err != nil {
+		return err
+	}
+
+	if c.Jobs != nil && c.config.Jobs.WorkInServer {
+		c.startWorkers()
+	}
+
+	c.Broadcast.Start()
+
+	c.server = &http.Server{
+		Addr:         fmt.Sprintf(":%s", c.config.Port),
 		ErrorLog:     c.ErrorLog,
 		Handler:      c.Routes,
 		IdleTimeout:  30 * time.Second,
@@ -225,21 +327,46 @@ func (c *Celeritas) ListenAndServe() {
 		WriteTimeout: 600 * time.Second,
 	}
 
-	if c.DB.Pool != nil {
-		defer c.DB.Pool.Close()
-	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 2)
+	go func() {
+		if c.tlsEnabled() {
+			c.server.TLSConfig = c.tlsConfig()
+			c.InfoLog.Printf("Listening for HTTPS on port %s", c.config.Port)
+			serverErr <- c.server.ListenAndServeTLS(c.config.TLS.CertFile, c.config.TLS.KeyFile)
+			return
+		}
 
-	if redisPool != nil {
-		defer redisPool.Close()
+		c.InfoLog.Printf("Listening on port %s", c.config.Port)
+		serverErr <- c.server.ListenAndServe()
+	}()
+
+	if c.tlsEnabled() && c.config.TLS.RedirectPort != "" {
+		c.redirectServer = c.newRedirectServer()
+		go func() {
+			c.InfoLog.Printf("Redirecting HTTP on port %s to HTTPS", c.config.TLS.RedirectPort)
+			serverErr <- c.redirectServer.ListenAndServe()
+		}()
 	}
 
-	if badgerConn != nil {
-		defer badgerConn.Close()
+	select {
+	case err = <-serverErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+	case <-ctx.Done():
+		c.InfoLog.Println("Shutting down...")
 	}
 
-	c.InfoLog.Printf("Listening on port %s", os.Getenv("PORT"))
-	err := srv.ListenAndServe()
-	c.ErrorLog.Fatal(err)
+	// restore default signal behaviour, so a second signal kills the process immediately
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), c.config.ShutdownTimeout)
+	defer cancel()
+
+	return errors.Join(err, c.Shutdown(shutdownCtx))
 }
 
 func (c *Celeritas) checkDotEnv(path string) error {
@@ -250,67 +377,162 @@ func (c *Celeritas) checkDotEnv(path string) error {
 	return nil
 }
 
-func (c *Celeritas) startLoggers() (*log.Logger, *log.Logger) {
-	var infoLog *log.Logger
-	var errorLog *log.Logger
+// startLoggers creates the structured logger, and the InfoLog and ErrorLog loggers which
+// write through it at info and error level respectively
+func (c *Celeritas) startLoggers(rootPath string) (*log.Logger, *log.Logger, error) {
+	l, closer, err := logger.New(logger.Options{
+		Level:      c.config.Log.Level,
+		Format:     c.config.Log.Format,
+		Output:     c.config.Log.Output,
+		File:       fmt.Sprintf("%s/logs/%s.log", rootPath, c.config.AppName),
+		MaxSize:    c.config.Log.MaxSize,
+		MaxBackups: c.config.Log.MaxBackups,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
 
-	infoLog = log.New(os.Stdout, "INFO\t", log.Ldate|log.Ltime)
-	errorLog = log.New(os.Stdout, "ERROR\t", log.Ldate|log.Ltime|log.Lshortfile)
+	c.Logger = l
+	c.logCloser = closer
 
-	return infoLog, errorLog
+	infoLog := slog.NewLogLogger(l.Handler(), slog.LevelInfo)
+	errorLog := slog.NewLogLogger(l.Handler(), slog.LevelError)
+
+	return infoLog, errorLog, nil
 }
 
 func (c *Celeritas) createRenderer() {
 	myRenderer := render.Render{
-		Renderer: c.config.renderer,
+		Renderer: c.config.Renderer,
 		RootPath: c.RootPath,
-		Port:     c.config.port,
+		Port:     c.config.Port,
 		JetViews: c.JetViews,
+		Views:    c.files("views"),
+		Debug:    c.Debug,
 		Session:  c.Session,
 	}
 	c.Render = &myRenderer
 }
 
+// AddTemplateFunc makes a function available to both Go and Jet templates under name
+func (c *Celeritas) AddTemplateFunc(name string, fn interface{}) {
+	c.Render.AddFunc(name, fn)
+}
+
 func (c *Celeritas) createMailer() mailer.Mail {
-	port, _ := strconv.Atoi(os.Getenv("SMTP_PORT"))
 	m := mailer.Mail{
-		Domain:      os.Getenv("MAIL_DOMAIN"),
+		Domain:      c.config.Mail.Domain,
 		Templates:   c.RootPath + "/mail",
-		Host:        os.Getenv("SMTP_HOST"),
-		Port:        port,
-		Username:    os.Getenv("SMTP_USERNAME"),
-		Password:    os.Getenv("SMTP_PASSWORD"),
-		Encryption:  os.Getenv("SMTP_ENCRYPTION"),
-		FromAddress: os.Getenv("MAIL_FROM_NAME"),
-		FromName:    os.Getenv("MAIL_FROM_ADDRESS"),
+		Files:       c.files("mail"),
+		Host:        c.config.Mail.SMTPHost,
+		Port:        c.config.Mail.SMTPPort,
+		Username:    c.config.Mail.SMTPUsername,
+		Password:    c.config.Mail.SMTPPassword,
+		Encryption:  c.config.Mail.SMTPEncryption,
+		FromAddress: c.config.Mail.FromAddress,
+		FromName:    c.config.Mail.FromName,
 		Jobs:        make(chan mailer.Message, 20),
 		Results:     make(chan mailer.Result, 20),
-		API:         os.Getenv("MAILER_API"),
-		APIKey:      os.Getenv("MAILER_KEY"),
-		APIUrl:      os.Getenv("MAILER_URL"),
+		Done:        make(chan struct{}),
+		Quit:        make(chan struct{}),
+		API:         c.config.Mail.API,
+		APIKey:      c.config.Mail.APIKey,
+		APIUrl:      c.config.Mail.APIUrl,
+		Events:      c.Events,
 	}
 
 	return m
 }
 
+// BuildDSN builds the connection string for the configured database
 func (c *Celeritas) BuildDSN() string {
-	var dsn string
-
-	switch os.Getenv("DATABASE_TYPE") {
-	case "postgres", "postgresql":
-		dsn = fmt.Sprintf("host=%s port=%s user=%s dbname=%s sslmode=%s timezone=UTC connect_timeout=5",
-			os.Getenv("DATABASE_HOST"),
-			os.Getenv("DATABASE_PORT"),
-			os.Getenv("DATABASE_USER"),
-			os.Getenv("DATABASE_NAME"),
-			os.Getenv("DATABASE_SSL_MODE"),
-		)
-		if os.Getenv("DATABASE_PASS") != "" {
-			dsn = fmt.Sprintf("%s password=%s", dsn, os.Getenv("DATABASE_PASS"))
+	return c.config.Database.DSN()
+}
+
+// createScheduler creates the scheduler, which uses the cache for tasks that run on one server,
+// and schedules the framework's own maintenance tasks
+func (c *Celeritas) createScheduler() *scheduler.Scheduler {
+	s := scheduler.New(c.Logger)
+
+	if locker, ok := c.Cache.(cache.Locker); ok {
+		s.Locker = locker
+	}
+
+	if badgerConn != nil {
+		s.Call("cache:gc", func(ctx context.Context) error {
+			return badgerGC(badgerConn)
+		}).Every(12 * time.Hour)
+	}
+
+	if badgerJobsConn != nil {
+		s.Call("jobs:gc", func(ctx context.Context) error {
+			return badgerGC(badgerJobsConn)
+		}).Every(12 * time.Hour)
+	}
+
+	return s
+}
+
+// badgerGC cleans up the value log of db
+func badgerGC(db *badger.DB) error {
+	err := db.RunValueLogGC(0.7)
+	if errors.Is(err, badger.ErrNoRewrite) {
+		// nothing to clean up
+		return nil
+	}
+	return err
+}
+
+// createBroadcaster creates the broadcasting hub, which fans messages out through redis pub/sub
+// when redis is the cache, so that every instance of the application receives them
+func (c *Celeritas) createBroadcaster() *broadcast.Hub {
+	var pool *redis.Pool
+	if c.config.Cache == "redis" {
+		pool = redisPool
+	}
+
+	return broadcast.New(c.Logger, c.Session, pool, c.config.Redis.Prefix)
+}
+
+// createJobQueue creates the job queue, reusing the redis pool or database pool of the
+// application where one is open already. Badger jobs get a database of their own, which
+// emptying the cache cannot reach
+func (c *Celeritas) createJobQueue() *jobs.Queue {
+	q := &jobs.Queue{
+		Logger:      c.Logger,
+		Workers:     c.config.Jobs.Workers,
+		MaxAttempts: c.config.Jobs.MaxAttempts,
+		Timeout:     c.config.Jobs.Timeout,
+	}
+
+	switch c.config.Jobs.Backend {
+	case "redis":
+		if redisPool == nil {
+			redisPool = c.createRedisPool()
+		}
+		q.Backend = &jobs.RedisBackend{Conn: redisPool, Prefix: c.config.Redis.Prefix}
+	case "database":
+		q.Backend = &jobs.DatabaseBackend{DB: c.DB.Pool, Type: c.DB.Type}
+	case "badger":
+		if badgerJobsConn == nil {
+			badgerJobsConn = c.createBadgerConnAt(c.RootPath + "/tmp/badger-jobs")
 		}
+		q.Backend = &jobs.BadgerBackend{Conn: badgerJobsConn}
 	}
 
-	return dsn
+	return q
+}
+
+// startWorkers processes jobs in the background, until the application shuts down
+func (c *Celeritas) startWorkers() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.stopWorkers = cancel
+	c.workersDone = make(chan struct{})
+
+	go func() {
+		defer close(c.workersDone)
+		_ = c.Jobs.Work(ctx, c.config.Jobs.Queues...)
+	}()
 }
 
 func (c *Celeritas) createBadgerCache() *cache.BadgerCache {
@@ -322,7 +544,11 @@ func (c *Celeritas) createBadgerCache() *cache.BadgerCache {
 }
 
 func (c *Celeritas) createBadgerConn() *badger.DB {
-	db, err := badger.Open(badger.DefaultOptions(c.RootPath + "/tmp/badger"))
+	return c.createBadgerConnAt(c.RootPath + "/tmp/badger")
+}
+
+func (c *Celeritas) createBadgerConnAt(dir string) *badger.DB {
+	db, err := badger.Open(badger.DefaultOptions(dir))
 
 	if err != nil {
 		return nil
@@ -333,7 +559,7 @@ func (c *Celeritas) createBadgerConn() *badger.DB {
 func (c *Celeritas) createRedisCache() *cache.RedisCache {
 	cacheClient := cache.RedisCache{
 		Conn:   c.createRedisPool(),
-		Prefix: c.config.redis.prefix,
+		Prefix: c.config.Redis.Prefix,
 	}
 	return &cacheClient
 }
@@ -346,8 +572,8 @@ func (c *Celeritas) createRedisPool() *redis.Pool {
 		Dial: func() (redis.Conn, error) {
 			return redis.Dial(
 				"tcp",
-				c.config.redis.host,
-				redis.DialPassword(c.config.redis.password))
+				c.config.Redis.Host,
+				redis.DialPassword(c.config.Redis.Password))
 		},
 		TestOnBorrow: func(conn redis.Conn, t time.Time) error {
 			_, err := conn.Do("PING")
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+)
+
+// doAppCommand passes a command the cli does not know on to the application, which runs
+// the commands added by the framework and its providers, e.g. schedule:list
+func doAppCommand(args []string) error {
+	cmd := exec.Command("go", append([]string{"run", "."}, args...)...)
+	cmd.Dir = cel.RootPath
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
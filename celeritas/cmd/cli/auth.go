@@ -8,7 +8,7 @@ import (
 
 func doAuth() error {
 	// migrations
-	dbType := cel.DB.Type
+	dbType := templateDBType()
 	fileName := fmt.Sprintf("%d_create_auth_tables", time.Now().UnixMicro())
 	upFile := cel.RootPath + "/migrations/" + fileName + ".up.sql"
 	downFile := cel.RootPath + "/migrations/" + fileName + ".down.sql"
@@ -18,7 +18,13 @@ func doAuth() error {
 		exitGracefully(err)
 	}
 
-	err = copyDataToFile([]byte("drop table if exists users cascade; drop table if exists tokens cascade; drop table if exists remember_tokens cascade;"), downFile)
+	down := "drop table if exists users cascade; drop table if exists tokens cascade; drop table if exists remember_tokens cascade;"
+	if dbType == "sqlite" {
+		// sqlite has no cascade, so drop the tables referencing users first
+		down = "drop table if exists tokens; drop table if exists remember_tokens; drop table if exists users;"
+	}
+
+	err = copyDataToFile([]byte(down), downFile)
 	if err != nil {
 		exitGracefully(err)
 	}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"github.com/fatih/color"
+	"math/big"
+	"net"
+	"os"
+	"time"
+)
+
+// doCert creates a self-signed certificate and key in the tls directory, for serving
+// https during development. It is valid for localhost, the loopback addresses, the
+// configured server name and host, if given
+func doCert(host string) error {
+	certFile := cel.RootPath + "/tls/cert.pem"
+	keyFile := cel.RootPath + "/tls/key.pem"
+
+	if fileExists(certFile) || fileExists(keyFile) {
+		return errors.New("certificate already exists in " + cel.RootPath + "/tls")
+	}
+
+	err := os.MkdirAll(cel.RootPath+"/tls", 0755)
+	if err != nil {
+		return err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"Celeritas development"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+	}
+
+	for _, h := range []string{cfg.ServerName, host} {
+		if h == "" || h == "localhost" {
+			continue
+		}
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	err = copyDataToFile(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), certFile)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}), 0600)
+	if err != nil {
+		return err
+	}
+
+	color.Yellow("  - self-signed certificate created in tls/")
+	color.Yellow("")
+	color.Yellow("  - To use it, set the following in .env:")
+	color.Yellow("      SECURE=true")
+	color.Yellow("      TLS_CERT_FILE=tls/cert.pem")
+	color.Yellow("      TLS_KEY_FILE=tls/key.pem")
+	color.Yellow("  - Your browser will warn that the certificate is not trusted; that is expected.")
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+func doFeaturesTable() error {
+	dbType := templateDBType()
+
+	fileName := fmt.Sprintf("%d_create_feature_flags_table", time.Now().UnixMicro())
+
+	upFile := cel.RootPath + "/migrations/" + fileName + "." + dbType + ".up.sql"
+	downFile := cel.RootPath + "/migrations/" + fileName + "." + dbType + ".down.sql"
+
+	err := copyFileFromTemplate("templates/migrations/feature_flags_table."+dbType+".sql", upFile)
+	if err != nil {
+		exitGracefully(err)
+	}
+
+	err = copyDataToFile([]byte("drop table if exists feature_flags;"), downFile)
+	if err != nil {
+		exitGracefully(err)
+	}
+
+	err = doMigrate("up", "")
+	if err != nil {
+		exitGracefully(err)
+	}
+
+	return nil
+}
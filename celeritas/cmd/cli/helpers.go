@@ -1,9 +1,8 @@
 package main
 
 import (
-	"fmt"
 	"github.com/fatih/color"
-	"github.com/joho/godotenv"
+	"github.com/fouched/celeritas"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,51 +10,46 @@ import (
 
 func setup(arg1, arg2 string) {
 	if arg1 != "new" && arg1 != "version" && arg1 != "help" {
-		err := godotenv.Load()
+		path, err := os.Getwd()
 		if err != nil {
 			exitGracefully(err)
 		}
 
-		path, err := os.Getwd()
+		cfg, err = celeritas.LoadConfig(path)
 		if err != nil {
 			exitGracefully(err)
 		}
+		cfg.ResolvePaths(path)
+
+		if cfg.Database.Type == "sqlite" {
+			// sqlite creates the database file when migrating, but not the folder it lives in
+			err = os.MkdirAll(filepath.Dir(cfg.Database.Name), 0755)
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
 
 		cel.RootPath = path
-		cel.DB.Type = os.Getenv("DATABASE_TYPE")
+		cel.DB.Type = cfg.Database.Type
 	}
 }
 
 func getDSN() string {
-	dbType := cel.DB.Type
-
-	//we use pgx, but golang migrate uses different driver
-	//so convert dsn to work with golang migrate
-	if dbType == "pgx" {
-		dbType = "postgres"
-	}
+	// golang migrate uses different drivers to the ones we connect with,
+	// so it needs the dsn in its own format
+	return cfg.Database.MigrateDSN()
+}
 
-	if dbType == "postgres" {
-		var dsn string
-		if os.Getenv("DATABASE_PASS") != "" {
-			dsn = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
-				os.Getenv("DATABASE_USER"),
-				os.Getenv("DATABASE_PASS"),
-				os.Getenv("DATABASE_HOST"),
-				os.Getenv("DATABASE_PORT"),
-				os.Getenv("DATABASE_NAME"),
-				os.Getenv("DATABASE_SSL_MODE"))
-		} else {
-			dsn = fmt.Sprintf("postgres://%s@%s:%s/%s?sslmode=%s",
-				os.Getenv("DATABASE_USER"),
-				os.Getenv("DATABASE_HOST"),
-				os.Getenv("DATABASE_PORT"),
-				os.Getenv("DATABASE_NAME"),
-				os.Getenv("DATABASE_SSL_MODE"))
-		}
-		return dsn
+// templateDBType returns the database type used in template and migration file names,
+// which only distinguish between postgres and mysql
+func templateDBType() string {
+	switch cel.DB.Type {
+	case "mariadb":
+		return "mysql"
+	case "postgresql":
+		return "postgres"
 	}
-	return "mysql://" + cel.BuildDSN()
+	return cel.DB.Type
 }
 
 func showHelp() {
@@ -64,16 +58,33 @@ func showHelp() {
     help                     - show help
     version                  - print version
     make auth                - creates authentication tables, models and middleware
+    make cert <host>         - creates a self-signed development certificate in the tls directory
     make handler <name>      - creates a stub handler in the handlers directory
     make key                 - creates a random 32 character encryption key
     make mail <name>         - creates starter templates for text and html emails in the mail directory
     make model <name>        - creates a new model in the data directory
     make session             - creates a new table as a session store
+    make jobs                - creates the tables for the database job queue
+    make features            - creates the table for feature flags stored in the database
     
     make migration <name>    - creates new up and down migrations
     migrate                  - runs all up migrations
     migrate down             - reverses most recent migration
     migrate reset            - runs all down migrations, then all up migrations
+
+    down [--retry=<seconds>] [--allow=<ips>] [--secret=<path>|--with-secret]
+                             - puts the application in maintenance mode
+    up                       - takes the application out of maintenance mode
+
+    schedule:list            - lists the scheduled tasks and when they run next
+    jobs:work [queues]       - processes queued jobs until interrupted
+    jobs:failed              - lists the jobs that failed
+    jobs:retry <id|all>      - puts failed jobs back on their queue
+    features:list            - lists the feature flags and whether they are on
+    features:enable <name>   - turns a feature flag on for everyone
+    features:disable <name>  - turns a feature flag off, except for its users and rollout
+    list                     - lists the commands added by the application and its providers
+    <command> [args]         - runs a command added by the application or one of its providers
     `)
 }
 
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+func doJobsTables() error {
+	dbType := templateDBType()
+
+	fileName := fmt.Sprintf("%d_create_jobs_tables", time.Now().UnixMicro())
+
+	upFile := cel.RootPath + "/migrations/" + fileName + "." + dbType + ".up.sql"
+	downFile := cel.RootPath + "/migrations/" + fileName + "." + dbType + ".down.sql"
+
+	err := copyFileFromTemplate("templates/migrations/jobs_tables."+dbType+".sql", upFile)
+	if err != nil {
+		exitGracefully(err)
+	}
+
+	err = copyDataToFile([]byte("drop table if exists failed_jobs; drop table if exists jobs;"), downFile)
+	if err != nil {
+		exitGracefully(err)
+	}
+
+	err = doMigrate("up", "")
+	if err != nil {
+		exitGracefully(err)
+	}
+
+	return nil
+}
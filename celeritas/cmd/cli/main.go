@@ -10,6 +10,7 @@ import (
 const version = "1.0.0"
 
 var cel celeritas.Celeritas
+var cfg celeritas.Config
 
 func main() {
 	var message string
@@ -47,8 +48,21 @@ func main() {
 		if err != nil {
 			exitGracefully(err)
 		}
+	case "down":
+		err = doDown(os.Args[2:])
+		if err != nil {
+			exitGracefully(err)
+		}
+	case "up":
+		err = doUp()
+		if err != nil {
+			exitGracefully(err)
+		}
 	default:
-		showHelp()
+		err = doAppCommand(os.Args[1:])
+		if err != nil {
+			exitGracefully(err)
+		}
 	}
 
 	exitGracefully(nil, message)
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"github.com/fatih/color"
+	"github.com/fouched/celeritas"
+	"strings"
+)
+
+// doDown puts the application in maintenance mode, e.g.
+//
+//	celeritas down --retry=60 --allow=10.0.0.0/8,203.0.113.7 --with-secret
+func doDown(args []string) error {
+	flags := flag.NewFlagSet("down", flag.ContinueOnError)
+	retry := flags.Int("retry", 0, "seconds clients are asked to wait before retrying")
+	allow := flags.String("allow", "", "comma separated IPs or CIDRs that can still use the application")
+	secret := flags.String("secret", "", "path that sets a cookie to bypass maintenance mode")
+	withSecret := flags.Bool("with-secret", false, "generate a secret bypass path")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	m := celeritas.Maintenance{Retry: *retry, Secret: *secret}
+	for _, entry := range strings.Split(*allow, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			m.Allow = append(m.Allow, entry)
+		}
+	}
+
+	if m.Secret == "" && *withSecret {
+		b := make([]byte, 16)
+		_, err = rand.Read(b)
+		if err != nil {
+			return err
+		}
+		m.Secret = hex.EncodeToString(b)
+	}
+
+	err = cel.Down(m)
+	if err != nil {
+		return err
+	}
+
+	color.Yellow("Application is now in maintenance mode.")
+	if m.Secret != "" {
+		color.Yellow("Visit %s/%s to bypass it.", strings.TrimSuffix(cfg.AppURL, "/"), m.Secret)
+	}
+
+	return nil
+}
+
+// doUp takes the application out of maintenance mode
+func doUp() error {
+	err := cel.Up()
+	if err != nil {
+		return err
+	}
+
+	color.Yellow("Application is now live.")
+	return nil
+}
@@ -16,7 +16,7 @@ func doMake(arg2, arg3 string) error {
 		rnd := cel.RandomString(32)
 		color.Yellow("32 character encryption key: %s", rnd)
 	case "migration":
-		dbType := cel.DB.Type
+		dbType := templateDBType()
 		if arg3 == "" {
 			exitGracefully(errors.New("you must give the migration a name"))
 		}
@@ -34,6 +34,11 @@ func doMake(arg2, arg3 string) error {
 		if err != nil {
 			exitGracefully(err)
 		}
+	case "cert":
+		err := doCert(arg3)
+		if err != nil {
+			exitGracefully(err)
+		}
 	case "auth":
 		err := doAuth()
 		if err != nil {
@@ -108,6 +113,16 @@ func doMake(arg2, arg3 string) error {
 		if err != nil {
 			exitGracefully(err)
 		}
+	case "jobs":
+		err := doJobsTables()
+		if err != nil {
+			exitGracefully(err)
+		}
+	case "features":
+		err := doFeaturesTable()
+		if err != nil {
+			exitGracefully(err)
+		}
 
 	}
 
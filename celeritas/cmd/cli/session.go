@@ -6,15 +6,7 @@ import (
 )
 
 func doSessionTable() error {
-	dbType := cel.DB.Type
-
-	if dbType == "mariadb" {
-		dbType = "mysql"
-	}
-
-	if dbType == "postgresql" {
-		dbType = "postgres"
-	}
+	dbType := templateDBType()
 
 	fileName := fmt.Sprintf("%d_create_sessions_table", time.Now().UnixMicro())
 
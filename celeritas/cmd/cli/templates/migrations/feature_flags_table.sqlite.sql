@@ -0,0 +1,9 @@
+CREATE TABLE feature_flags (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL,
+    enabled INTEGER NOT NULL,
+    percentage INTEGER NOT NULL,
+    users TEXT NOT NULL,
+    environments TEXT NOT NULL,
+    updated_at INTEGER NOT NULL
+);
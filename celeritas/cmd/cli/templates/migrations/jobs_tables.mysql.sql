@@ -0,0 +1,28 @@
+CREATE TABLE jobs (
+    id VARCHAR(32) PRIMARY KEY,
+    queue VARCHAR(255) NOT NULL,
+    type VARCHAR(255) NOT NULL,
+    payload LONGTEXT NOT NULL,
+    attempts INT NOT NULL,
+    max_attempts INT NOT NULL,
+    available_at BIGINT NOT NULL,
+    created_at BIGINT NOT NULL,
+    error LONGTEXT NOT NULL
+);
+
+CREATE INDEX jobs_queue_available_at_idx ON jobs (queue, available_at);
+
+CREATE TABLE failed_jobs (
+    id VARCHAR(32) PRIMARY KEY,
+    queue VARCHAR(255) NOT NULL,
+    type VARCHAR(255) NOT NULL,
+    payload LONGTEXT NOT NULL,
+    attempts INT NOT NULL,
+    max_attempts INT NOT NULL,
+    available_at BIGINT NOT NULL,
+    created_at BIGINT NOT NULL,
+    error LONGTEXT NOT NULL,
+    failed_at BIGINT NOT NULL
+);
+
+CREATE INDEX failed_jobs_failed_at_idx ON failed_jobs (failed_at);
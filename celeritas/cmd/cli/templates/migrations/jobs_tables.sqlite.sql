@@ -0,0 +1,28 @@
+CREATE TABLE jobs (
+    id TEXT PRIMARY KEY,
+    queue TEXT NOT NULL,
+    type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    attempts INTEGER NOT NULL,
+    max_attempts INTEGER NOT NULL,
+    available_at INTEGER NOT NULL,
+    created_at INTEGER NOT NULL,
+    error TEXT NOT NULL
+);
+
+CREATE INDEX jobs_queue_available_at_idx ON jobs (queue, available_at);
+
+CREATE TABLE failed_jobs (
+    id TEXT PRIMARY KEY,
+    queue TEXT NOT NULL,
+    type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    attempts INTEGER NOT NULL,
+    max_attempts INTEGER NOT NULL,
+    available_at INTEGER NOT NULL,
+    created_at INTEGER NOT NULL,
+    error TEXT NOT NULL,
+    failed_at INTEGER NOT NULL
+);
+
+CREATE INDEX failed_jobs_failed_at_idx ON failed_jobs (failed_at);
@@ -0,0 +1,7 @@
+-- CREATE TABLE some_table (
+--     id int(10) unsigned NOT NULL AUTO_INCREMENT,
+--     some_field VARCHAR ( 255 ) NOT NULL,
+--     created_at TIMESTAMP NOT NULL DEFAULT current_timestamp(),
+--     updated_at TIMESTAMP NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp(),
+--     PRIMARY KEY (id)
+-- ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
\ No newline at end of file
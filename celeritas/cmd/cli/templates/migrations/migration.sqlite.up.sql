@@ -0,0 +1,14 @@
+-- CREATE TABLE some_table (
+--     id INTEGER PRIMARY KEY AUTOINCREMENT,
+--     some_field TEXT NOT NULL,
+--     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+--     updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+-- );
+
+-- add auto update of updated_at
+-- CREATE TRIGGER some_table_set_timestamp
+--     AFTER UPDATE ON some_table
+--     FOR EACH ROW
+-- BEGIN
+--     UPDATE some_table SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+-- END;
\ No newline at end of file
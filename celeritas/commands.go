@@ -0,0 +1,262 @@
+package celeritas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+// Command is a command that the application binary can run instead of serving requests,
+// e.g. `go run . schedule:list`. The celeritas CLI passes commands it does not know on to the application
+type Command struct {
+	Name        string
+	Description string
+	Run         func(c *Celeritas, args []string) error
+}
+
+// ErrUnknownCommand is returned by RunCommand when no command with the given name was added
+var ErrUnknownCommand = errors.New("unknown command")
+
+// AddCommand adds a command to the application, replacing any command with the same name
+func (c *Celeritas) AddCommand(cmd Command) {
+	if c.commands == nil {
+		c.commands = make(map[string]Command)
+	}
+	c.commands[cmd.Name] = cmd
+}
+
+// Commands returns the commands added to the application, sorted by name
+func (c *Celeritas) Commands() []Command {
+	commands := make([]Command, 0, len(c.commands))
+	for _, cmd := range c.commands {
+		commands = append(commands, cmd)
+	}
+
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+
+	return commands
+}
+
+// RunCommand boots the providers and runs the command named by args[0], passing it the remaining
+// arguments. The command "list" prints the available commands
+func (c *Celeritas) RunCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New("command required")
+	}
+
+	err := c.Boot()
+	if err != nil {
+		return err
+	}
+
+	if args[0] == "list" {
+		for _, cmd := range c.Commands() {
+			fmt.Printf("    %-24s - %s\n", cmd.Name, cmd.Description)
+		}
+		return nil
+	}
+
+	cmd, ok := c.commands[args[0]]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownCommand, args[0])
+	}
+
+	return cmd.Run(c, args[1:])
+}
+
+// addDefaultCommands adds the commands that come with the framework
+func (c *Celeritas) addDefaultCommands() {
+	c.AddCommand(Command{
+		Name:        "schedule:list",
+		Description: "lists the scheduled tasks and when they run next",
+		Run:         scheduleList,
+	})
+
+	c.AddCommand(Command{
+		Name:        "jobs:work",
+		Description: "processes queued jobs until interrupted, from JOBS_QUEUES or the queues given",
+		Run:         jobsWork,
+	})
+
+	c.AddCommand(Command{
+		Name:        "jobs:failed",
+		Description: "lists the jobs that failed",
+		Run:         jobsFailed,
+	})
+
+	c.AddCommand(Command{
+		Name:        "jobs:retry",
+		Description: "puts the failed job with the given id, or all failed jobs, back on their queue",
+		Run:         jobsRetry,
+	})
+
+	c.AddCommand(Command{
+		Name:        "features:list",
+		Description: "lists the feature flags and whether they are on in this environment",
+		Run:         featuresList,
+	})
+
+	c.AddCommand(Command{
+		Name:        "features:enable",
+		Description: "turns the feature flag with the given name on for everyone",
+		Run:         featuresToggle(true),
+	})
+
+	c.AddCommand(Command{
+		Name:        "features:disable",
+		Description: "turns the feature flag with the given name off, except for its users and rollout",
+		Run:         featuresToggle(false),
+	})
+}
+
+func scheduleList(c *Celeritas, args []string) error {
+	now := time.Now()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "TASK\tSCHEDULE\tNEXT RUN\tONE SERVER")
+
+	for _, t := range c.Scheduler.Tasks() {
+		next := "-"
+		if n := t.Next(now); !n.IsZero() {
+			next = fmt.Sprintf("%s (in %s)", n.Format(time.DateTime), n.Sub(now).Round(time.Second))
+		}
+
+		oneServer := ""
+		if t.RunsOnOneServer() {
+			oneServer = "yes"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.Name, t.Spec(), next, oneServer)
+	}
+
+	return w.Flush()
+}
+
+// errNoJobs is returned by the jobs commands when JOBS_BACKEND is not set
+var errNoJobs = errors.New("the job queue is not configured, set JOBS_BACKEND in .env")
+
+func jobsWork(c *Celeritas, args []string) error {
+	if c.Jobs == nil {
+		return errNoJobs
+	}
+
+	queues := args
+	if len(queues) == 0 {
+		queues = c.config.Jobs.Queues
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return c.Jobs.Work(ctx, queues...)
+}
+
+func jobsFailed(c *Celeritas, args []string) error {
+	if c.Jobs == nil {
+		return errNoJobs
+	}
+
+	failed, err := c.Jobs.Failed()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "ID\tQUEUE\tTYPE\tATTEMPTS\tFAILED AT\tERROR")
+
+	for _, job := range failed {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n",
+			job.ID, job.Queue, job.Type, job.Attempts, job.FailedAt.Format(time.DateTime), job.Error)
+	}
+
+	return w.Flush()
+}
+
+func jobsRetry(c *Celeritas, args []string) error {
+	if c.Jobs == nil {
+		return errNoJobs
+	}
+
+	if len(args) == 0 {
+		return errors.New("jobs:retry requires the id of a failed job, or all")
+	}
+
+	ids := args
+	if args[0] == "all" {
+		failed, err := c.Jobs.Failed()
+		if err != nil {
+			return err
+		}
+
+		ids = nil
+		for _, job := range failed {
+			ids = append(ids, job.ID)
+		}
+	}
+
+	for _, id := range ids {
+		err := c.Jobs.Retry(id)
+		if err != nil {
+			return fmt.Errorf("retry job %s: %w", id, err)
+		}
+		fmt.Println("Retrying job", id)
+	}
+
+	return nil
+}
+
+func featuresList(c *Celeritas, args []string) error {
+	flags, err := c.Features.Flags()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "FLAG\tON IN %s\tROLLOUT\tUSERS\tDESCRIPTION\n", strings.ToUpper(c.config.Environment))
+
+	for _, f := range flags {
+		on := "no"
+		if c.Features.EnabledFor(f, 0) {
+			on = "yes"
+		}
+
+		rollout := "-"
+		if f.Percentage > 0 {
+			rollout = fmt.Sprintf("%d%%", f.Percentage)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", f.Name, on, rollout, len(f.Users), f.Description)
+	}
+
+	return w.Flush()
+}
+
+func featuresToggle(enabled bool) func(c *Celeritas, args []string) error {
+	return func(c *Celeritas, args []string) error {
+		if len(args) == 0 {
+			return errors.New("the name of a feature flag is required")
+		}
+
+		err := c.Features.Toggle(args[0], enabled)
+		if err != nil {
+			return err
+		}
+
+		state := "off"
+		if enabled {
+			state = "on"
+		}
+		fmt.Printf("Feature %s is now %s\n", args[0], state)
+
+		return nil
+	}
+}
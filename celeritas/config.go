@@ -0,0 +1,919 @@
+package celeritas
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fouched/celeritas/logger"
+	"github.com/go-sql-driver/mysql"
+	"github.com/joho/godotenv"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds all settings for a Celeritas application. It is normally read from the .env
+// file and the environment by LoadConfig, but can also be built by hand and passed to
+// NewWithConfig, e.g. in tests
+type Config struct {
+	AppName         string
+	AppURL          string
+	Environment     string // e.g. production or staging; defaults to development when DEBUG is on
+	Debug           bool
+	Port            string
+	ServerName      string
+	Secure          bool
+	Renderer        string
+	Cache           string
+	SessionType     string
+	Key             string
+	ShutdownTimeout time.Duration
+	Cookie          CookieConfig
+	Database        DatabaseConfig
+	Redis           RedisConfig
+	Mail            MailConfig
+	Log             LogConfig
+	TLS             TLSConfig
+	Jobs            JobsConfig
+	Events          EventsConfig
+	CORS            CORSConfig
+	Security        SecurityConfig
+	Metrics         MetricsConfig
+	Tracing         TracingConfig
+	Features        FeaturesConfig
+	I18n            I18nConfig
+	Filesystem      FilesystemConfig
+	Upload          UploadConfig
+}
+
+type CookieConfig struct {
+	Name     string
+	Lifetime int // in minutes
+	Persist  bool
+	Secure   bool
+	Domain   string
+}
+
+type DatabaseConfig struct {
+	Type            string
+	Host            string
+	Port            string
+	User            string
+	Password        string
+	Name            string // for sqlite, the database file
+	SSLMode         string
+	Charset         string // mysql/mariadb only
+	Collation       string // mysql/mariadb only
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime *time.Duration // 0 keeps connections open indefinitely; 5 minutes when nil
+	ConnectAttempts int
+	ConnectBackoff  time.Duration // wait before the first retry, doubled after every attempt
+}
+
+type RedisConfig struct {
+	Host     string
+	Password string
+	Prefix   string
+}
+
+type MailConfig struct {
+	Domain         string
+	FromName       string
+	FromAddress    string
+	SMTPHost       string
+	SMTPUsername   string
+	SMTPPassword   string
+	SMTPPort       int
+	SMTPEncryption string
+	API            string
+	APIKey         string
+	APIUrl         string
+	HealthCheck    bool // NOOP the SMTP server in /readyz
+}
+
+type TLSConfig struct {
+	CertFile     string // relative to the application root, unless absolute
+	KeyFile      string
+	RedirectPort string // if set, plain HTTP on this port is redirected to HTTPS
+	HSTSMaxAge   time.Duration
+}
+
+type JobsConfig struct {
+	Backend      string // redis, database or badger; empty disables the job queue
+	Workers      int
+	Queues       []string // processed in order of priority
+	MaxAttempts  int
+	Timeout      time.Duration
+	WorkInServer bool // process jobs in the web server as well, e.g. for badger, which cannot be shared with a worker process
+}
+
+type CORSConfig struct {
+	AllowedOrigins   []string // e.g. https://example.com or https://*.example.com; * allows any origin
+	AllowedMethods   []string
+	AllowedHeaders   []string // * allows any header
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration // how long browsers may cache a preflight response
+	Paths            []string      // where addMiddleware applies CORS; a trailing * matches a prefix
+}
+
+// SecurityConfig holds the security headers set on every response. {nonce} in CSP is replaced
+// by a random nonce for every request, and a header set to - is left out
+type SecurityConfig struct {
+	CSP               string
+	CSPReportOnly     bool // report violations without blocking anything, e.g. while tightening the policy
+	FrameOptions      string
+	ReferrerPolicy    string
+	PermissionsPolicy string
+}
+
+type MetricsConfig struct {
+	Disabled bool // metrics are on unless METRICS_ENABLED is false
+	Path     string
+	Token    string // if set, scrapers must send it as a bearer token
+}
+
+type TracingConfig struct {
+	Exporter    string   // stdout, file or otlp; empty disables tracing
+	File        string   // where the file exporter writes spans, relative to the application root unless absolute
+	Endpoint    string   // OTLP/HTTP collector URL, e.g. http://localhost:4318
+	SampleRatio *float64 // fraction of new traces recorded, from 0 to 1; all of them when nil
+}
+
+type FeaturesConfig struct {
+	Store    string        // database keeps flags in the feature_flags table; empty only uses flags defined in code
+	CacheTTL time.Duration // how long flags read from the database are cached
+}
+
+type I18nConfig struct {
+	DefaultLocale string // used when the request does not ask for a locale with a catalog in lang/
+	Cookie        string // the cookie that remembers the locale chosen by the user
+	NoURLPrefix   bool   // don't take the locale from the first path segment, e.g. /fr/about
+}
+
+type FilesystemConfig struct {
+	Default string                // the disk used when no name is given
+	Disks   map[string]DiskConfig // by name, e.g. local or s3
+}
+
+// DiskConfig configures a disk called NAME from the DISK_NAME_* variables
+type DiskConfig struct {
+	Driver   string // local or s3
+	Root     string // local: the directory, defaults to storage/<name>
+	URL      string // local: where temporary URLs point, defaults to APP_URL/storage/<name>
+	Endpoint string // s3: e.g. http://localhost:9000 for MinIO; defaults to AWS
+	Region   string
+	Bucket   string
+	Key      string
+	Secret   string
+}
+
+type UploadConfig struct {
+	MaxSize      int64    // per file, in bytes; UPLOAD_MAX_SIZE is in megabytes
+	AllowedTypes []string // detected from the contents of the file, e.g. image/png or image/*
+}
+
+type EventsConfig struct {
+	Workers   int // number of asynchronous listeners that run at the same time
+	QueueSize int // number of events waiting for an asynchronous listener before Publish blocks
+}
+
+type LogConfig struct {
+	Level      string // debug, info, warn or error
+	Format     string // text or json
+	Output     string // stdout, file or both
+	MaxSize    int    // in megabytes
+	MaxBackups int
+}
+
+// sqlitePragmas are applied to every SQLite connection: enforce foreign keys, wait for
+// locks instead of failing immediately and allow reads while writing
+const sqlitePragmas = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+
+// DSN builds the connection string used to open the database
+func (d DatabaseConfig) DSN() string {
+	var dsn string
+
+	switch d.Type {
+	case "postgres", "postgresql":
+		dsn = fmt.Sprintf("host=%s port=%s user=%s dbname=%s sslmode=%s timezone=UTC connect_timeout=5",
+			d.Host,
+			d.Port,
+			d.User,
+			d.Name,
+			d.SSLMode,
+		)
+		if d.Password != "" {
+			dsn = fmt.Sprintf("%s password=%s", dsn, d.Password)
+		}
+	case "mysql", "mariadb":
+		dsn = d.mysqlConfig().FormatDSN()
+	case "sqlite":
+		dsn = "file:" + d.Name + "?" + sqlitePragmas
+	}
+
+	return dsn
+}
+
+// MigrateDSN builds the connection string in the URL form golang-migrate expects
+func (d DatabaseConfig) MigrateDSN() string {
+	switch d.Type {
+	case "postgres", "postgresql":
+		u := url.URL{
+			Scheme:   "postgres",
+			User:     url.User(d.User),
+			Host:     net.JoinHostPort(d.Host, d.Port),
+			Path:     d.Name,
+			RawQuery: "sslmode=" + url.QueryEscape(d.SSLMode),
+		}
+		if d.Password != "" {
+			u.User = url.UserPassword(d.User, d.Password)
+		}
+		return u.String()
+	case "mysql", "mariadb":
+		// migrations may contain more than one statement per file
+		cfg := d.mysqlConfig()
+		cfg.MultiStatements = true
+		// golang-migrate treats any tls value other than true, false and skip-verify as the
+		// name of a custom TLS config, so fall back to an unverified connection for prefer
+		if cfg.TLSConfig == "preferred" {
+			cfg.TLSConfig = "skip-verify"
+		}
+		return "mysql://" + cfg.FormatDSN()
+	case "sqlite":
+		return "sqlite://" + filepath.ToSlash(d.Name) + "?" + sqlitePragmas
+	}
+
+	return ""
+}
+
+// mysqlConfig translates the database settings to the mysql driver's configuration
+func (d DatabaseConfig) mysqlConfig() *mysql.Config {
+	cfg := mysql.NewConfig()
+	cfg.User = d.User
+	cfg.Passwd = d.Password
+	cfg.Net = "tcp"
+	cfg.Addr = d.Host
+	if d.Port != "" {
+		cfg.Addr = net.JoinHostPort(d.Host, d.Port)
+	}
+	cfg.DBName = d.Name
+	cfg.ParseTime = true
+	cfg.Loc = time.UTC
+	cfg.Collation = d.Collation
+	cfg.Params = map[string]string{"charset": d.Charset}
+	cfg.Timeout = 5 * time.Second
+	cfg.ReadTimeout = 30 * time.Second
+	cfg.WriteTimeout = 30 * time.Second
+
+	// accept the postgres style ssl modes, so DATABASE_SSL_MODE means the same for both
+	switch strings.ToLower(d.SSLMode) {
+	case "", "disable", "false":
+		cfg.TLSConfig = "false"
+	case "skip-verify", "allow":
+		cfg.TLSConfig = "skip-verify"
+	case "preferred", "prefer":
+		cfg.TLSConfig = "preferred"
+	default:
+		// require, verify-ca, verify-full, true
+		cfg.TLSConfig = "true"
+	}
+
+	return cfg
+}
+
+// ResolvePaths makes the file paths in the configuration, i.e. the SQLite database, the
+// TLS certificate and key, the trace file and the roots of local disks, absolute by resolving them relative to rootPath
+func (cfg *Config) ResolvePaths(rootPath string) {
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(rootPath, p)
+	}
+
+	if cfg.Database.Type == "sqlite" {
+		cfg.Database.Name = resolve(cfg.Database.Name)
+	}
+
+	cfg.TLS.CertFile = resolve(cfg.TLS.CertFile)
+	cfg.TLS.KeyFile = resolve(cfg.TLS.KeyFile)
+	cfg.Tracing.File = resolve(cfg.Tracing.File)
+
+	for name, disk := range cfg.Filesystem.Disks {
+		if disk.Driver == "local" {
+			disk.Root = resolve(disk.Root)
+			cfg.Filesystem.Disks[name] = disk
+		}
+	}
+}
+
+// LoadConfig loads the .env file in rootPath into the environment, and reads a Config from
+// the environment. Variables that are already set in the environment take precedence over .env,
+// and a missing .env is treated as empty, e.g. in deployments configured by the environment alone
+func LoadConfig(rootPath string) (Config, error) {
+	err := godotenv.Load(rootPath + "/.env")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Config{}, err
+	}
+
+	return ReadConfig(environment())
+}
+
+// environment returns the process environment as a map
+func environment() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		env[key] = value
+	}
+
+	return env
+}
+
+// ReadConfig builds a Config from a map of environment variables, applying defaults for
+// missing values. All values that cannot be converted to the right type are reported
+// together in the returned error
+func ReadConfig(env map[string]string) (Config, error) {
+	r := envReader{env: env}
+
+	cfg := Config{
+		AppName:         r.string("APP_NAME"),
+		AppURL:          r.string("APP_URL"),
+		Environment:     r.string("APP_ENV"),
+		Debug:           r.bool("DEBUG", false),
+		Port:            r.string("PORT"),
+		ServerName:      r.string("SERVER_NAME"),
+		Secure:          r.bool("SECURE", true),
+		Renderer:        r.string("RENDERER"),
+		Cache:           r.string("CACHE"),
+		SessionType:     r.string("SESSION_TYPE"),
+		Key:             r.string("KEY"),
+		ShutdownTimeout: r.seconds("SHUTDOWN_TIMEOUT"),
+		Cookie: CookieConfig{
+			Name:     r.string("COOKIE_NAME"),
+			Lifetime: r.int("COOKIE_LIFETIME"),
+			Persist:  r.bool("COOKIE_PERSIST", false),
+			Secure:   r.bool("COOKIE_SECURE", false),
+			Domain:   r.string("COOKIE_DOMAIN"),
+		},
+		Database: DatabaseConfig{
+			Type:            r.string("DATABASE_TYPE"),
+			Host:            r.string("DATABASE_HOST"),
+			Port:            r.string("DATABASE_PORT"),
+			User:            r.string("DATABASE_USER"),
+			Password:        r.string("DATABASE_PASS"),
+			Name:            r.string("DATABASE_NAME"),
+			SSLMode:         r.string("DATABASE_SSL_MODE"),
+			Charset:         r.string("DATABASE_CHARSET"),
+			Collation:       r.string("DATABASE_COLLATION"),
+			MaxOpenConns:    r.int("DATABASE_MAX_OPEN_CONNS"),
+			MaxIdleConns:    r.int("DATABASE_MAX_IDLE_CONNS"),
+			ConnMaxLifetime: r.optionalSeconds("DATABASE_CONN_MAX_LIFETIME"),
+			ConnectAttempts: r.int("DATABASE_CONNECT_ATTEMPTS"),
+			ConnectBackoff:  r.seconds("DATABASE_CONNECT_BACKOFF"),
+		},
+		Redis: RedisConfig{
+			Host:     r.string("REDIS_HOST"),
+			Password: r.string("REDIS_PASSWORD"),
+			Prefix:   r.string("REDIS_PREFIX"),
+		},
+		Mail: MailConfig{
+			Domain:         r.string("MAIL_DOMAIN"),
+			FromName:       r.string("MAIL_FROM_NAME"),
+			FromAddress:    r.string("MAIL_FROM_ADDRESS"),
+			SMTPHost:       r.string("SMTP_HOST"),
+			SMTPUsername:   r.string("SMTP_USERNAME"),
+			SMTPPassword:   r.string("SMTP_PASSWORD"),
+			SMTPPort:       r.int("SMTP_PORT"),
+			SMTPEncryption: r.string("SMTP_ENCRYPTION"),
+			API:            r.string("MAILER_API"),
+			APIKey:         r.string("MAILER_KEY"),
+			APIUrl:         r.string("MAILER_URL"),
+			HealthCheck:    r.bool("MAIL_HEALTH_CHECK", false),
+		},
+		Log: LogConfig{
+			Level:      r.string("LOG_LEVEL"),
+			Format:     r.string("LOG_FORMAT"),
+			Output:     r.string("LOG_OUTPUT"),
+			MaxSize:    r.int("LOG_MAX_SIZE"),
+			MaxBackups: r.int("LOG_MAX_BACKUPS"),
+		},
+		TLS: TLSConfig{
+			CertFile:     r.string("TLS_CERT_FILE"),
+			KeyFile:      r.string("TLS_KEY_FILE"),
+			RedirectPort: r.string("TLS_REDIRECT_PORT"),
+			HSTSMaxAge:   r.seconds("HSTS_MAX_AGE"),
+		},
+		Jobs: JobsConfig{
+			Backend:      r.string("JOBS_BACKEND"),
+			Workers:      r.int("JOBS_WORKERS"),
+			Queues:       r.list("JOBS_QUEUES"),
+			MaxAttempts:  r.int("JOBS_MAX_ATTEMPTS"),
+			Timeout:      r.seconds("JOBS_TIMEOUT"),
+			WorkInServer: r.bool("JOBS_WORK_IN_SERVER", false),
+		},
+		CORS: CORSConfig{
+			AllowedOrigins:   r.list("CORS_ALLOWED_ORIGINS"),
+			AllowedMethods:   r.list("CORS_ALLOWED_METHODS"),
+			AllowedHeaders:   r.list("CORS_ALLOWED_HEADERS"),
+			ExposedHeaders:   r.list("CORS_EXPOSED_HEADERS"),
+			AllowCredentials: r.bool("CORS_ALLOW_CREDENTIALS", false),
+			MaxAge:           r.seconds("CORS_MAX_AGE"),
+			Paths:            r.list("CORS_PATHS"),
+		},
+		Security: SecurityConfig{
+			CSP:               r.string("SECURITY_CSP"),
+			CSPReportOnly:     r.bool("SECURITY_CSP_REPORT_ONLY", false),
+			FrameOptions:      r.string("SECURITY_FRAME_OPTIONS"),
+			ReferrerPolicy:    r.string("SECURITY_REFERRER_POLICY"),
+			PermissionsPolicy: r.string("SECURITY_PERMISSIONS_POLICY"),
+		},
+		Metrics: MetricsConfig{
+			Disabled: !r.bool("METRICS_ENABLED", true),
+			Path:     r.string("METRICS_PATH"),
+			Token:    r.string("METRICS_TOKEN"),
+		},
+		Tracing: TracingConfig{
+			Exporter:    r.string("TRACING_EXPORTER"),
+			File:        r.string("TRACING_FILE"),
+			Endpoint:    r.string("TRACING_ENDPOINT"),
+			SampleRatio: r.float("TRACING_SAMPLE_RATIO"),
+		},
+		Features: FeaturesConfig{
+			Store:    r.string("FEATURES_STORE"),
+			CacheTTL: r.seconds("FEATURES_CACHE_TTL"),
+		},
+		I18n: I18nConfig{
+			DefaultLocale: r.string("I18N_DEFAULT_LOCALE"),
+			Cookie:        r.string("I18N_COOKIE"),
+			NoURLPrefix:   !r.bool("I18N_URL_PREFIX", true),
+		},
+		Filesystem: FilesystemConfig{
+			Default: r.string("FILESYSTEM_DISK"),
+			Disks:   make(map[string]DiskConfig),
+		},
+		Upload: UploadConfig{
+			MaxSize:      int64(r.int("UPLOAD_MAX_SIZE")) << 20,
+			AllowedTypes: r.list("UPLOAD_ALLOWED_TYPES"),
+		},
+		Events: EventsConfig{
+			Workers:   r.int("EVENTS_WORKERS"),
+			QueueSize: r.int("EVENTS_QUEUE_SIZE"),
+		},
+	}
+
+	for _, name := range r.list("FILESYSTEM_DISKS") {
+		prefix := "DISK_" + strings.ToUpper(name) + "_"
+		cfg.Filesystem.Disks[name] = DiskConfig{
+			Driver:   r.string(prefix + "DRIVER"),
+			Root:     r.string(prefix + "ROOT"),
+			URL:      r.string(prefix + "URL"),
+			Endpoint: r.string(prefix + "ENDPOINT"),
+			Region:   r.string(prefix + "REGION"),
+			Bucket:   r.string(prefix + "BUCKET"),
+			Key:      r.string(prefix + "KEY"),
+			Secret:   r.string(prefix + "SECRET"),
+		}
+	}
+
+	cfg.applyDefaults()
+
+	return cfg, errors.Join(r.errs...)
+}
+
+// applyDefaults fills in sensible values for settings that were left empty
+func (cfg *Config) applyDefaults() {
+	if cfg.AppName == "" {
+		cfg.AppName = "celeritas"
+	}
+
+	if cfg.Port == "" {
+		cfg.Port = "4000"
+	}
+
+	if cfg.Environment == "" {
+		cfg.Environment = "production"
+		if cfg.Debug {
+			cfg.Environment = "development"
+		}
+	}
+
+	if cfg.Renderer == "" {
+		cfg.Renderer = "jet"
+	}
+
+	if cfg.SessionType == "" {
+		cfg.SessionType = "cookie"
+	}
+
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = 30 * time.Second
+	}
+
+	if cfg.Cookie.Name == "" {
+		cfg.Cookie.Name = cfg.AppName
+	}
+
+	if cfg.Cookie.Lifetime <= 0 {
+		cfg.Cookie.Lifetime = 60
+	}
+
+	if cfg.Redis.Prefix == "" {
+		cfg.Redis.Prefix = cfg.AppName
+	}
+
+	if cfg.Database.SSLMode == "" {
+		cfg.Database.SSLMode = "disable"
+	}
+
+	if cfg.Log.Level == "" {
+		cfg.Log.Level = "info"
+	}
+
+	if cfg.Log.Format == "" {
+		cfg.Log.Format = "text"
+	}
+
+	if cfg.Log.Output == "" {
+		cfg.Log.Output = "stdout"
+	}
+
+	if cfg.Log.MaxSize == 0 {
+		cfg.Log.MaxSize = 10
+	}
+
+	if cfg.Log.MaxBackups == 0 {
+		cfg.Log.MaxBackups = 5
+	}
+
+	if cfg.Database.Type == "sqlite" && cfg.Database.Name == "" {
+		cfg.Database.Name = "db-data/sqlite/" + cfg.AppName + ".db"
+	}
+
+	if cfg.Database.Charset == "" {
+		cfg.Database.Charset = "utf8mb4"
+	}
+
+	if cfg.Database.Collation == "" {
+		cfg.Database.Collation = "utf8mb4_unicode_ci"
+	}
+
+	if cfg.Database.MaxOpenConns == 0 {
+		cfg.Database.MaxOpenConns = 25
+	}
+
+	if cfg.Database.MaxIdleConns == 0 {
+		cfg.Database.MaxIdleConns = 25
+	}
+
+	if cfg.Database.ConnMaxLifetime == nil {
+		lifetime := 5 * time.Minute
+		cfg.Database.ConnMaxLifetime = &lifetime
+	}
+
+	if cfg.Database.ConnectAttempts == 0 {
+		cfg.Database.ConnectAttempts = 5
+	}
+
+	if cfg.Database.ConnectBackoff == 0 {
+		cfg.Database.ConnectBackoff = time.Second
+	}
+
+	if cfg.Jobs.Workers == 0 {
+		cfg.Jobs.Workers = 5
+	}
+
+	if len(cfg.Jobs.Queues) == 0 {
+		cfg.Jobs.Queues = []string{"default"}
+	}
+
+	if cfg.Jobs.MaxAttempts == 0 {
+		cfg.Jobs.MaxAttempts = 3
+	}
+
+	if cfg.Jobs.Timeout == 0 {
+		cfg.Jobs.Timeout = 5 * time.Minute
+	}
+
+	cfg.CORS.applyDefaults()
+	cfg.Security.applyDefaults()
+
+	if cfg.Metrics.Path == "" {
+		cfg.Metrics.Path = "/metrics"
+	}
+
+	if cfg.Tracing.File == "" {
+		cfg.Tracing.File = "logs/traces.json"
+	}
+
+	if cfg.Tracing.SampleRatio == nil {
+		ratio := 1.0
+		cfg.Tracing.SampleRatio = &ratio
+	}
+
+	if cfg.Features.CacheTTL == 0 {
+		cfg.Features.CacheTTL = time.Minute
+	}
+
+	if cfg.I18n.DefaultLocale == "" {
+		cfg.I18n.DefaultLocale = "en"
+	}
+
+	if cfg.I18n.Cookie == "" {
+		cfg.I18n.Cookie = "lang"
+	}
+
+	if len(cfg.Filesystem.Disks) == 0 {
+		cfg.Filesystem.Disks = map[string]DiskConfig{"local": {}}
+	}
+
+	for name, disk := range cfg.Filesystem.Disks {
+		if disk.Driver == "" {
+			disk.Driver = "local"
+		}
+		if disk.Driver == "local" && disk.Root == "" {
+			disk.Root = "storage/" + name
+		}
+		if disk.Driver == "local" && disk.URL == "" && cfg.AppURL != "" {
+			disk.URL = strings.TrimSuffix(cfg.AppURL, "/") + "/storage/" + name
+		}
+		cfg.Filesystem.Disks[name] = disk
+	}
+
+	if cfg.Filesystem.Default == "" {
+		cfg.Filesystem.Default = "local"
+	}
+
+	if cfg.Upload.MaxSize == 0 {
+		cfg.Upload.MaxSize = 10 << 20
+	}
+
+	if len(cfg.Upload.AllowedTypes) == 0 {
+		cfg.Upload.AllowedTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp", "application/pdf"}
+	}
+
+	if cfg.Events.Workers == 0 {
+		cfg.Events.Workers = 4
+	}
+
+	if cfg.Events.QueueSize == 0 {
+		cfg.Events.QueueSize = 100
+	}
+}
+
+// Validate checks that the configuration is usable, and returns a single error listing
+// every problem found
+func (cfg *Config) Validate() error {
+	var errs []error
+
+	if len(cfg.Key) != 32 {
+		errs = append(errs, fmt.Errorf("KEY must be exactly 32 characters long, got %d", len(cfg.Key)))
+	}
+
+	if _, err := strconv.Atoi(cfg.Port); err != nil {
+		errs = append(errs, fmt.Errorf("PORT must be a number, got %q", cfg.Port))
+	}
+
+	switch strings.ToLower(cfg.Renderer) {
+	case "go", "jet":
+	default:
+		errs = append(errs, fmt.Errorf("RENDERER must be go or jet, got %q", cfg.Renderer))
+	}
+
+	switch cfg.Cache {
+	case "", "redis", "badger":
+	default:
+		errs = append(errs, fmt.Errorf("CACHE must be empty, redis or badger, got %q", cfg.Cache))
+	}
+
+	switch cfg.Database.Type {
+	case "":
+	case "postgres", "postgresql", "mysql", "mariadb":
+		if cfg.Database.Host == "" {
+			errs = append(errs, errors.New("DATABASE_HOST is required when DATABASE_TYPE is set"))
+		}
+		if cfg.Database.User == "" {
+			errs = append(errs, errors.New("DATABASE_USER is required when DATABASE_TYPE is set"))
+		}
+		if cfg.Database.Name == "" {
+			errs = append(errs, errors.New("DATABASE_NAME is required when DATABASE_TYPE is set"))
+		}
+	case "sqlite":
+	default:
+		errs = append(errs, fmt.Errorf("DATABASE_TYPE must be postgres, postgresql, mysql, mariadb or sqlite, got %q", cfg.Database.Type))
+	}
+
+	if cfg.Database.MaxOpenConns < 0 || cfg.Database.MaxIdleConns < 0 || (cfg.Database.ConnMaxLifetime != nil && *cfg.Database.ConnMaxLifetime < 0) {
+		errs = append(errs, errors.New("DATABASE_MAX_OPEN_CONNS, DATABASE_MAX_IDLE_CONNS and DATABASE_CONN_MAX_LIFETIME cannot be negative"))
+	}
+
+	if cfg.Database.ConnectAttempts < 1 {
+		errs = append(errs, fmt.Errorf("DATABASE_CONNECT_ATTEMPTS must be at least 1, got %d", cfg.Database.ConnectAttempts))
+	}
+
+	if _, err := logger.ParseLevel(cfg.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL must be debug, info, warn or error, got %q", cfg.Log.Level))
+	}
+
+	switch strings.ToLower(cfg.Log.Format) {
+	case "text", "json":
+	default:
+		errs = append(errs, fmt.Errorf("LOG_FORMAT must be text or json, got %q", cfg.Log.Format))
+	}
+
+	switch strings.ToLower(cfg.Log.Output) {
+	case "stdout", "file", "both":
+	default:
+		errs = append(errs, fmt.Errorf("LOG_OUTPUT must be stdout, file or both, got %q", cfg.Log.Output))
+	}
+
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		errs = append(errs, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together"))
+	}
+
+	if cfg.TLS.RedirectPort != "" {
+		if _, err := strconv.Atoi(cfg.TLS.RedirectPort); err != nil {
+			errs = append(errs, fmt.Errorf("TLS_REDIRECT_PORT must be a number, got %q", cfg.TLS.RedirectPort))
+		} else if cfg.TLS.RedirectPort == cfg.Port {
+			errs = append(errs, errors.New("TLS_REDIRECT_PORT must differ from PORT"))
+		}
+	}
+
+	if cfg.TLS.HSTSMaxAge < 0 {
+		errs = append(errs, errors.New("HSTS_MAX_AGE cannot be negative"))
+	}
+
+	switch strings.ToLower(cfg.SessionType) {
+	case "cookie":
+	case "redis":
+		if cfg.Redis.Host == "" {
+			errs = append(errs, errors.New("REDIS_HOST is required when SESSION_TYPE is redis"))
+		}
+	case "mysql", "mariadb", "postgres", "postgresql", "sqlite":
+		if cfg.Database.Type == "" {
+			errs = append(errs, fmt.Errorf("DATABASE_TYPE is required when SESSION_TYPE is %s", cfg.SessionType))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("SESSION_TYPE must be cookie, redis, mysql, mariadb, postgres, postgresql or sqlite, got %q", cfg.SessionType))
+	}
+
+	if cfg.Cache == "redis" && cfg.Redis.Host == "" {
+		errs = append(errs, errors.New("REDIS_HOST is required when CACHE is redis"))
+	}
+
+	switch cfg.Jobs.Backend {
+	case "", "badger":
+	case "redis":
+		if cfg.Redis.Host == "" {
+			errs = append(errs, errors.New("REDIS_HOST is required when JOBS_BACKEND is redis"))
+		}
+	case "database":
+		if cfg.Database.Type == "" {
+			errs = append(errs, errors.New("DATABASE_TYPE is required when JOBS_BACKEND is database"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("JOBS_BACKEND must be empty, redis, database or badger, got %q", cfg.Jobs.Backend))
+	}
+
+	if cfg.Jobs.Workers < 1 || cfg.Jobs.MaxAttempts < 1 || cfg.Jobs.Timeout < 0 {
+		errs = append(errs, errors.New("JOBS_WORKERS and JOBS_MAX_ATTEMPTS must be at least 1, and JOBS_TIMEOUT cannot be negative"))
+	}
+
+	for _, origin := range cfg.CORS.AllowedOrigins {
+		if strings.Count(origin, "*") > 1 {
+			errs = append(errs, fmt.Errorf("CORS_ALLOWED_ORIGINS may only contain one * per origin, got %q", origin))
+		}
+	}
+
+	switch strings.ToLower(cfg.Tracing.Exporter) {
+	case "", "stdout", "file", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("TRACING_EXPORTER must be empty, stdout, file or otlp, got %q", cfg.Tracing.Exporter))
+	}
+
+	if ratio := cfg.Tracing.SampleRatio; ratio != nil && (*ratio < 0 || *ratio > 1) {
+		errs = append(errs, fmt.Errorf("TRACING_SAMPLE_RATIO must be between 0 and 1, got %g", *ratio))
+	}
+
+	switch cfg.Features.Store {
+	case "":
+	case "database":
+		if cfg.Database.Type == "" {
+			errs = append(errs, errors.New("DATABASE_TYPE is required when FEATURES_STORE is database"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("FEATURES_STORE must be empty or database, got %q", cfg.Features.Store))
+	}
+
+	if _, ok := cfg.Filesystem.Disks[cfg.Filesystem.Default]; !ok {
+		errs = append(errs, fmt.Errorf("FILESYSTEM_DISK must be one of FILESYSTEM_DISKS, got %q", cfg.Filesystem.Default))
+	}
+
+	for name, disk := range cfg.Filesystem.Disks {
+		prefix := "DISK_" + strings.ToUpper(name) + "_"
+		switch disk.Driver {
+		case "local":
+		case "s3":
+			if disk.Bucket == "" {
+				errs = append(errs, fmt.Errorf("%sBUCKET is required when %sDRIVER is s3", prefix, prefix))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("%sDRIVER must be local or s3, got %q", prefix, disk.Driver))
+		}
+	}
+
+	if cfg.Upload.MaxSize < 0 {
+		errs = append(errs, errors.New("UPLOAD_MAX_SIZE cannot be negative"))
+	}
+
+	if cfg.Events.Workers < 1 || cfg.Events.QueueSize < 0 {
+		errs = append(errs, errors.New("EVENTS_WORKERS must be at least 1, and EVENTS_QUEUE_SIZE cannot be negative"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// envReader converts environment variables to typed values, collecting every
+// conversion error instead of stopping at the first one
+type envReader struct {
+	env  map[string]string
+	errs []error
+}
+
+func (r *envReader) string(key string) string {
+	return strings.TrimSpace(r.env[key])
+}
+
+func (r *envReader) bool(key string, def bool) bool {
+	v := r.string(key)
+	if v == "" {
+		return def
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be true or false, got %q", key, v))
+		return def
+	}
+	return b
+}
+
+func (r *envReader) int(key string) int {
+	v := r.string(key)
+	if v == "" {
+		return 0
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be a whole number, got %q", key, v))
+		return 0
+	}
+	return i
+}
+
+// float returns nil when key is not set, so that an explicit 0 can be told apart from the default
+func (r *envReader) float(key string) *float64 {
+	v := r.string(key)
+	if v == "" {
+		return nil
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be a number, got %q", key, v))
+		return nil
+	}
+	return &f
+}
+
+// list splits a comma separated value, ignoring empty items
+func (r *envReader) list(key string) []string {
+	var items []string
+	for _, item := range strings.Split(r.string(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (r *envReader) seconds(key string) time.Duration {
+	return time.Duration(r.int(key)) * time.Second
+}
+
+// optionalSeconds returns nil when key is not set, so that an explicit 0 can be told apart
+// from the default
+func (r *envReader) optionalSeconds(key string) *time.Duration {
+	if r.string(key) == "" {
+		return nil
+	}
+
+	d := r.seconds(key)
+	return &d
+}
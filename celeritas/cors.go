@@ -0,0 +1,199 @@
+package celeritas
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// applyDefaults fills in the methods, headers and paths that are not configured
+func (cfg *CORSConfig) applyDefaults() {
+	if len(cfg.AllowedMethods) == 0 {
+		cfg.AllowedMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+	}
+
+	if len(cfg.AllowedHeaders) == 0 {
+		cfg.AllowedHeaders = []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"}
+	}
+
+	if len(cfg.Paths) == 0 {
+		cfg.Paths = []string{"/api/*"}
+	}
+}
+
+// CORS returns middleware that allows cross-origin requests as described by cfg, and answers
+// preflight requests. Paths in cfg is ignored, so it applies to every request it sees. To use
+// it for a group of routes, add it to a subrouter, so preflight requests, which have no route
+// of their own, still reach it, e.g.
+//
+//	a.App.Routes.Route("/partners", func(r chi.Router) {
+//		r.Use(a.App.CORS(celeritas.CORSConfig{AllowedOrigins: []string{"https://*.partner.com"}}))
+//		r.Get("/orders", a.Handlers.PartnerOrders)
+//	})
+func (c *Celeritas) CORS(cfg CORSConfig) func(http.Handler) http.Handler {
+	cfg.applyDefaults()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				// not a cross-origin request
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// the response depends on the origin, so caches must not share it between origins
+			w.Header().Add("Vary", "Origin")
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				cfg.preflight(w, r, origin)
+				return
+			}
+
+			if cfg.allowsOrigin(origin) {
+				cfg.allowOrigin(w, origin)
+				if len(cfg.ExposedHeaders) > 0 {
+					w.Header().Set("Access-Control-Expose-Headers", strings.Join(cfg.ExposedHeaders, ", "))
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// corsPaths returns middleware that applies the configured CORS policy to the configured
+// paths, if any origins are allowed
+func (c *Celeritas) corsPaths(next http.Handler) http.Handler {
+	if len(c.config.CORS.AllowedOrigins) == 0 {
+		return next
+	}
+
+	cors := c.CORS(c.config.CORS)(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, p := range c.config.CORS.Paths {
+			if matchPath(p, r.URL.Path) {
+				cors.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// preflight answers a preflight request. The CORS headers are left out if the origin, method
+// or headers are not allowed, which makes the browser refuse the actual request
+func (cfg CORSConfig) preflight(w http.ResponseWriter, r *http.Request, origin string) {
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	method := r.Header.Get("Access-Control-Request-Method")
+	headers := requestedHeaders(r)
+
+	if cfg.allowsOrigin(origin) && cfg.allowsMethod(method) && cfg.allowsHeaders(headers) {
+		cfg.allowOrigin(w, origin)
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(cfg.AllowedMethods, ", "))
+		if len(headers) > 0 {
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+		}
+		if cfg.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// allowOrigin sets the headers that let the browser share the response with origin
+func (cfg CORSConfig) allowOrigin(w http.ResponseWriter, origin string) {
+	if cfg.allowsAnyOrigin() && !cfg.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		// browsers refuse credentials with a wildcard, so name the origin instead
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+
+	if cfg.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (cfg CORSConfig) allowsAnyOrigin() bool {
+	for _, allowed := range cfg.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsOrigin reports whether origin is allowed, matching a wildcard in an allowed origin,
+// e.g. https://*.example.com, against one or more subdomains
+func (cfg CORSConfig) allowsOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+
+	for _, allowed := range cfg.AllowedOrigins {
+		allowed = strings.ToLower(allowed)
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+
+		prefix, suffix, ok := strings.Cut(allowed, "*")
+		if ok && len(origin) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (cfg CORSConfig) allowsMethod(method string) bool {
+	for _, allowed := range cfg.AllowedMethods {
+		if strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func (cfg CORSConfig) allowsHeaders(headers []string) bool {
+	for _, h := range headers {
+		found := false
+		for _, allowed := range cfg.AllowedHeaders {
+			if allowed == "*" || strings.EqualFold(allowed, h) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// requestedHeaders returns the headers a preflight request asks to send
+func requestedHeaders(r *http.Request) []string {
+	var headers []string
+	for _, value := range r.Header.Values("Access-Control-Request-Headers") {
+		for _, h := range strings.Split(value, ",") {
+			if h = strings.TrimSpace(h); h != "" {
+				headers = append(headers, h)
+			}
+		}
+	}
+	return headers
+}
+
+// matchPath reports whether path matches pattern, where a trailing * matches any suffix
+func matchPath(pattern, path string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(path, prefix)
+	}
+	return pattern == path
+}
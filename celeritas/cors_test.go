@@ -0,0 +1,106 @@
+package celeritas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var corsTests = []struct {
+	name        string
+	method      string
+	origin      string
+	headers     map[string]string
+	status      int
+	allowOrigin string
+	allowCreds  string
+}{
+	{"same-origin", "GET", "", nil, http.StatusOK, "", ""},
+	{"exact", "GET", "https://app.example.com", nil, http.StatusOK, "https://app.example.com", "true"},
+	{"wildcard-subdomain", "GET", "https://api.partner.com", nil, http.StatusOK, "https://api.partner.com", "true"},
+	{"wildcard-nested", "GET", "https://eu.api.partner.com", nil, http.StatusOK, "https://eu.api.partner.com", "true"},
+	{"wildcard-bare-domain", "GET", "https://partner.com", nil, http.StatusOK, "", ""},
+	{"other-origin", "GET", "https://evil.com", nil, http.StatusOK, "", ""},
+	{"preflight", "OPTIONS", "https://app.example.com", map[string]string{"Access-Control-Request-Method": "PUT", "Access-Control-Request-Headers": "content-type, authorization"}, http.StatusNoContent, "https://app.example.com", "true"},
+	{"preflight-bad-method", "OPTIONS", "https://app.example.com", map[string]string{"Access-Control-Request-Method": "TRACE"}, http.StatusNoContent, "", ""},
+	{"preflight-bad-header", "OPTIONS", "https://app.example.com", map[string]string{"Access-Control-Request-Method": "GET", "Access-Control-Request-Headers": "X-Secret"}, http.StatusNoContent, "", ""},
+	{"options-not-preflight", "OPTIONS", "https://app.example.com", nil, http.StatusOK, "https://app.example.com", "true"},
+}
+
+func TestCeleritas_CORS(t *testing.T) {
+	var c Celeritas
+	cors := c.CORS(CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.partner.com"},
+		ExposedHeaders:   []string{"RateLimit-Remaining"},
+		AllowCredentials: true,
+		MaxAge:           5 * time.Minute,
+	})
+	handler := cors(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, e := range corsTests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(e.method, "/api/users", nil)
+		if e.origin != "" {
+			r.Header.Set("Origin", e.origin)
+		}
+		for k, v := range e.headers {
+			r.Header.Set(k, v)
+		}
+
+		handler.ServeHTTP(w, r)
+
+		if w.Code != e.status {
+			t.Errorf("%s: expected status %d, got %d", e.name, e.status, w.Code)
+		}
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != e.allowOrigin {
+			t.Errorf("%s: expected allowed origin %q, got %q", e.name, e.allowOrigin, got)
+		}
+
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != e.allowCreds {
+			t.Errorf("%s: expected allow credentials %q, got %q", e.name, e.allowCreds, got)
+		}
+	}
+}
+
+func TestCeleritas_CORSPreflightHeaders(t *testing.T) {
+	var c Celeritas
+	handler := c.CORS(CORSConfig{AllowedOrigins: []string{"*"}, MaxAge: time.Minute})(http.NotFoundHandler())
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("OPTIONS", "/api/users", nil)
+	r.Header.Set("Origin", "https://anywhere.com")
+	r.Header.Set("Access-Control-Request-Method", "DELETE")
+	r.Header.Set("Access-Control-Request-Headers", "Content-Type")
+	handler.ServeHTTP(w, r)
+
+	expected := map[string]string{
+		"Access-Control-Allow-Origin":  "*",
+		"Access-Control-Allow-Methods": "GET, POST, PUT, PATCH, DELETE",
+		"Access-Control-Allow-Headers": "Content-Type",
+		"Access-Control-Max-Age":       "60",
+	}
+	for k, v := range expected {
+		if got := w.Header().Get(k); got != v {
+			t.Errorf("expected %s %q, got %q", k, v, got)
+		}
+	}
+}
+
+func TestCeleritas_CORSPaths(t *testing.T) {
+	cfg, _ := ReadConfig(map[string]string{"CORS_ALLOWED_ORIGINS": "*"})
+	c := Celeritas{config: cfg}
+	handler := c.corsPaths(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for path, allowed := range map[string]bool{"/api/users": true, "/users/login": false} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", path, nil)
+		r.Header.Set("Origin", "https://anywhere.com")
+		handler.ServeHTTP(w, r)
+
+		if (w.Header().Get("Access-Control-Allow-Origin") == "*") != allowed {
+			t.Errorf("%s: expected CORS %v", path, allowed)
+		}
+	}
+}
@@ -2,25 +2,85 @@ package celeritas
 
 import (
 	"database/sql"
+	"fmt"
+	"github.com/fouched/celeritas/tracing"
 	_ "github.com/jackc/pgx/v5"
 	_ "github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib"
+	"math/rand/v2"
+	_ "modernc.org/sqlite"
+	"time"
 )
 
+// maxConnectBackoff caps the wait between attempts to connect to the database
+const maxConnectBackoff = 30 * time.Second
+
+// OpenDB opens a database connection pool and pings it. If the ping fails, it is retried
+// with exponential backoff and jitter, up to the configured number of attempts, which
+// allows the database to start after the application, e.g. under docker-compose. When tracing
+// is enabled, queries made with a request context are recorded as spans
 func (c *Celeritas) OpenDB(dbType, dsn string) (*sql.DB, error) {
-	if dbType == "postgres" || dbType == "postgresql" {
-		dbType = "pgx"
+	driverName := dbType
+	switch dbType {
+	case "postgres", "postgresql":
+		driverName = "pgx"
+	case "mariadb":
+		driverName = "mysql"
 	}
 
-	db, err := sql.Open(dbType, dsn)
+	var db *sql.DB
+	var err error
+	if c.Tracing != nil {
+		db, err = tracing.OpenDB(driverName, dsn, dbType)
+	} else {
+		db, err = sql.Open(driverName, dsn)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	err = db.Ping()
-	if err != nil {
-		return nil, err
+	db.SetMaxOpenConns(c.config.Database.MaxOpenConns)
+	db.SetMaxIdleConns(c.config.Database.MaxIdleConns)
+	if lifetime := c.config.Database.ConnMaxLifetime; lifetime != nil {
+		db.SetConnMaxLifetime(*lifetime)
+	}
+
+	attempts := max(c.config.Database.ConnectAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		err = db.Ping()
+		if err == nil {
+			return db, nil
+		}
+
+		if attempt >= attempts {
+			break
+		}
+
+		wait := backoff(c.config.Database.ConnectBackoff, attempt)
+		if c.ErrorLog != nil {
+			c.ErrorLog.Printf("database not ready (attempt %d of %d), retrying in %s: %v", attempt, attempts, wait, err)
+		}
+		time.Sleep(wait)
+	}
+
+	_ = db.Close()
+	return nil, fmt.Errorf("could not connect to database after %d attempt(s): %w", attempts, err)
+}
+
+// backoff returns how long to wait after the given attempt: the base delay doubled for each
+// previous attempt and capped at maxConnectBackoff, with up to half of it randomised so
+// that several instances do not retry in lockstep
+func backoff(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		base = time.Second
+	}
+
+	wait := base
+	for i := 1; i < attempt && wait < maxConnectBackoff; i++ {
+		wait *= 2
 	}
+	wait = min(wait, maxConnectBackoff)
 
-	return db, nil
+	half := wait / 2
+	return half + rand.N(half+1)
 }
@@ -0,0 +1,78 @@
+package celeritas
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	base := 100 * time.Millisecond
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		wait := backoff(base, attempt)
+
+		expected := min(base<<(attempt-1), maxConnectBackoff)
+		if wait < expected/2 || wait > expected {
+			t.Errorf("attempt %d: wait %s outside of [%s, %s]", attempt, wait, expected/2, expected)
+		}
+	}
+}
+
+func TestCeleritas_OpenDB_Retries(t *testing.T) {
+	c := Celeritas{
+		config: Config{
+			Database: DatabaseConfig{
+				ConnectAttempts: 3,
+				ConnectBackoff:  time.Millisecond,
+			},
+		},
+	}
+
+	// nothing listens on port 1, so every ping fails
+	_, err := c.OpenDB("postgres", "host=127.0.0.1 port=1 user=nobody dbname=none sslmode=disable connect_timeout=1")
+	if err == nil {
+		t.Fatal("expected an error connecting to a database that is not running")
+	}
+
+	if !strings.Contains(err.Error(), "3 attempt(s)") {
+		t.Error("expected 3 connection attempts, got:", err)
+	}
+}
+
+func TestCeleritas_OpenDB_SQLite(t *testing.T) {
+	database := DatabaseConfig{
+		Type:            "sqlite",
+		Name:            filepath.Join(t.TempDir(), "test.db"),
+		ConnectAttempts: 1,
+		ConnectBackoff:  time.Millisecond,
+	}
+	c := Celeritas{config: Config{Database: database}}
+
+	db, err := c.OpenDB(database.Type, database.DSN())
+	if err != nil {
+		t.Fatal("could not open sqlite database:", err)
+	}
+	defer db.Close()
+
+	var foreignKeys int
+	err = db.QueryRow("pragma foreign_keys").Scan(&foreignKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if foreignKeys != 1 {
+		t.Error("expected foreign keys to be enforced")
+	}
+
+	var journalMode string
+	err = db.QueryRow("pragma journal_mode").Scan(&journalMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if journalMode != "wal" {
+		t.Error("expected wal journal mode, got", journalMode)
+	}
+}
@@ -0,0 +1,27 @@
+package celeritas
+
+// UserRegistered is published when a new user account is created
+type UserRegistered struct {
+	UserID int
+	Email  string
+}
+
+func (UserRegistered) Name() string { return "user.registered" }
+
+// PasswordReset is published when a user has reset their password
+type PasswordReset struct {
+	UserID int
+	Email  string
+}
+
+func (PasswordReset) Name() string { return "user.password_reset" }
+
+// LoginFailed is published when a login attempt fails, e.g. to lock accounts or alert on
+// brute force attempts
+type LoginFailed struct {
+	Email  string
+	IP     string
+	Reason string
+}
+
+func (LoginFailed) Name() string { return "user.login_failed" }
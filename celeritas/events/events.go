@@ -0,0 +1,207 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Event is something that happened, which listeners can react to. Events are matched to
+// listeners by name, e.g. "user.registered" or "cache.miss"
+type Event interface {
+	Name() string
+}
+
+// Listener reacts to an event
+type Listener func(ctx context.Context, e Event) error
+
+// All is the name to listen to every event with, e.g. for auditing
+const All = "*"
+
+// Bus delivers published events to their listeners. Synchronous listeners run before Publish
+// returns, in the order they were added; asynchronous listeners are queued and run by a pool of
+// workers. A listener that returns an error or panics is logged, and does not affect the
+// other listeners. A nil *Bus is valid, and discards everything published to it
+type Bus struct {
+	Logger *slog.Logger
+
+	mu      sync.RWMutex // guards the listeners
+	sync    map[string][]Listener
+	async   map[string][]Listener
+	closeMu sync.RWMutex // guards sending on queue against closing it
+	queue   chan delivery
+	workers sync.WaitGroup
+	closed  bool
+}
+
+// delivery is an event waiting for an asynchronous listener
+type delivery struct {
+	ctx      context.Context
+	event    Event
+	listener Listener
+}
+
+// New creates a bus that runs asynchronous listeners on workers goroutines, with room for
+// queueSize events waiting for a worker
+func New(logger *slog.Logger, workers, queueSize int) *Bus {
+	b := &Bus{
+		Logger: logger,
+		sync:   make(map[string][]Listener),
+		async:  make(map[string][]Listener),
+		queue:  make(chan delivery, queueSize),
+	}
+
+	for i := 0; i < max(workers, 1); i++ {
+		b.workers.Add(1)
+		go b.worker()
+	}
+
+	return b
+}
+
+// Listen adds a listener that runs synchronously when an event called name is published
+func (b *Bus) Listen(name string, l Listener) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sync[name] = append(b.sync[name], l)
+}
+
+// ListenAsync adds a listener that runs on a worker after an event called name is published
+func (b *Bus) ListenAsync(name string, l Listener) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.async[name] = append(b.async[name], l)
+}
+
+// Listen adds a synchronous listener for events of type T, e.g.
+//
+//	events.Listen(bus, func(ctx context.Context, e celeritas.LoginFailed) error { ... })
+//
+// T must be a value type whose zero value returns the name of the event
+func Listen[T Event](b *Bus, fn func(ctx context.Context, e T) error) {
+	var zero T
+	b.Listen(zero.Name(), typed(fn))
+}
+
+// ListenAsync adds an asynchronous listener for events of type T
+func ListenAsync[T Event](b *Bus, fn func(ctx context.Context, e T) error) {
+	var zero T
+	b.ListenAsync(zero.Name(), typed(fn))
+}
+
+func typed[T Event](fn func(ctx context.Context, e T) error) Listener {
+	return func(ctx context.Context, e Event) error {
+		te, ok := e.(T)
+		if !ok {
+			return fmt.Errorf("expected event of type %T, got %T", te, e)
+		}
+		return fn(ctx, te)
+	}
+}
+
+// Publish runs the synchronous listeners for e, and queues it for the asynchronous listeners.
+// When the queue is full, or after the bus is closed, asynchronous listeners run synchronously
+// instead, so events are not lost, and a listener publishing events cannot deadlock the workers
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	if b == nil {
+		return
+	}
+
+	// copy the listeners, so listeners can add listeners and publish events themselves
+	b.mu.RLock()
+	syncListeners := append(append([]Listener(nil), b.sync[e.Name()]...), b.sync[All]...)
+	asyncListeners := append(append([]Listener(nil), b.async[e.Name()]...), b.async[All]...)
+	b.mu.RUnlock()
+
+	for _, l := range syncListeners {
+		b.call(ctx, e, l)
+	}
+
+	// asynchronous listeners outlive the request that published the event
+	ctx = context.WithoutCancel(ctx)
+
+	for _, l := range asyncListeners {
+		if !b.enqueue(delivery{ctx: ctx, event: e, listener: l}) {
+			b.call(ctx, e, l)
+		}
+	}
+}
+
+// enqueue queues d for a worker without waiting, and reports false if the queue is full or
+// the bus is closed
+func (b *Bus) enqueue(d delivery) bool {
+	b.closeMu.RLock()
+	defer b.closeMu.RUnlock()
+
+	if b.closed {
+		return false
+	}
+
+	select {
+	case b.queue <- d:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops accepting asynchronous events, and waits until the queued events are handled,
+// or ctx expires
+func (b *Bus) Close(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+
+	b.closeMu.Lock()
+	if b.closed {
+		b.closeMu.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.queue)
+	b.closeMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("events: listeners still running: %w", ctx.Err())
+	}
+}
+
+func (b *Bus) worker() {
+	defer b.workers.Done()
+
+	for d := range b.queue {
+		b.call(d.ctx, d.event, d.listener)
+	}
+}
+
+// call runs a listener, logging its error or panic
+func (b *Bus) call(ctx context.Context, e Event, l Listener) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger().Error("event listener panicked", "event", e.Name(), "panic", r)
+		}
+	}()
+
+	if err := l(ctx, e); err != nil {
+		b.logger().Error("event listener failed", "event", e.Name(), "error", err)
+	}
+}
+
+func (b *Bus) logger() *slog.Logger {
+	if b.Logger == nil {
+		return slog.Default()
+	}
+	return b.Logger
+}
@@ -0,0 +1,227 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBus_ListenRunsInOrder(t *testing.T) {
+	bus := New(testLogger, 1, 1)
+	defer bus.Close(context.Background())
+
+	var got []string
+	for _, name := range []string{"first", "second", "third"} {
+		bus.Listen("user.registered", func(ctx context.Context, e Event) error {
+			got = append(got, name)
+			return nil
+		})
+	}
+
+	bus.Publish(context.Background(), userRegistered{Email: "me@here.com"})
+
+	if len(got) != 3 || got[0] != "first" || got[1] != "second" || got[2] != "third" {
+		t.Error("synchronous listeners did not run in order:", got)
+	}
+}
+
+func TestBus_ListenAsync(t *testing.T) {
+	bus := New(testLogger, 2, 10)
+
+	var count atomic.Int32
+	bus.ListenAsync("user.registered", func(ctx context.Context, e Event) error {
+		count.Add(1)
+		return nil
+	})
+
+	for i := 0; i < 5; i++ {
+		bus.Publish(context.Background(), userRegistered{})
+	}
+
+	// Close waits for the queued events
+	err := bus.Close(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count.Load() != 5 {
+		t.Error("expected 5 asynchronous deliveries, got", count.Load())
+	}
+}
+
+func TestBus_ListenAsyncOutlivesRequest(t *testing.T) {
+	bus := New(testLogger, 1, 1)
+
+	done := make(chan error, 1)
+	bus.ListenAsync("user.registered", func(ctx context.Context, e Event) error {
+		done <- ctx.Err()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bus.Publish(ctx, userRegistered{})
+	cancel()
+
+	_ = bus.Close(context.Background())
+
+	if err := <-done; err != nil {
+		t.Error("asynchronous listener got a cancelled context:", err)
+	}
+}
+
+func TestBus_ListenerFailures(t *testing.T) {
+	bus := New(testLogger, 1, 1)
+	defer bus.Close(context.Background())
+
+	ran := false
+	bus.Listen("user.registered", func(ctx context.Context, e Event) error {
+		panic("boom")
+	})
+	bus.Listen("user.registered", func(ctx context.Context, e Event) error {
+		return errors.New("failed")
+	})
+	bus.Listen("user.registered", func(ctx context.Context, e Event) error {
+		ran = true
+		return nil
+	})
+
+	bus.Publish(context.Background(), userRegistered{})
+
+	if !ran {
+		t.Error("a failing listener stopped the listeners after it")
+	}
+}
+
+func TestBus_All(t *testing.T) {
+	bus := New(testLogger, 1, 1)
+	defer bus.Close(context.Background())
+
+	var names []string
+	bus.Listen(All, func(ctx context.Context, e Event) error {
+		names = append(names, e.Name())
+		return nil
+	})
+
+	bus.Publish(context.Background(), userRegistered{})
+	bus.Publish(context.Background(), loginFailed{})
+
+	if len(names) != 2 || names[0] != "user.registered" || names[1] != "user.login_failed" {
+		t.Error("wildcard listener did not receive every event:", names)
+	}
+}
+
+func TestListen_Typed(t *testing.T) {
+	bus := New(testLogger, 1, 1)
+
+	var mu sync.Mutex
+	var emails []string
+	Listen(bus, func(ctx context.Context, e userRegistered) error {
+		mu.Lock()
+		defer mu.Unlock()
+		emails = append(emails, e.Email)
+		return nil
+	})
+	ListenAsync(bus, func(ctx context.Context, e userRegistered) error {
+		mu.Lock()
+		defer mu.Unlock()
+		emails = append(emails, "async:"+e.Email)
+		return nil
+	})
+
+	bus.Publish(context.Background(), userRegistered{Email: "me@here.com"})
+	bus.Publish(context.Background(), loginFailed{Email: "other@here.com"})
+	_ = bus.Close(context.Background())
+
+	if len(emails) != 2 || emails[0] != "me@here.com" || emails[1] != "async:me@here.com" {
+		t.Error("typed listeners received the wrong events:", emails)
+	}
+}
+
+func TestBus_PublishAfterClose(t *testing.T) {
+	bus := New(testLogger, 1, 1)
+
+	ran := false
+	bus.ListenAsync("user.registered", func(ctx context.Context, e Event) error {
+		ran = true
+		return nil
+	})
+
+	_ = bus.Close(context.Background())
+	bus.Publish(context.Background(), userRegistered{})
+
+	if !ran {
+		t.Error("event published after Close was lost")
+	}
+}
+
+func TestBus_PublishFromListenerWithFullQueue(t *testing.T) {
+	bus := New(testLogger, 1, 1)
+
+	var count atomic.Int32
+	bus.ListenAsync("user.login_failed", func(ctx context.Context, e Event) error {
+		count.Add(1)
+		return nil
+	})
+
+	// the only worker fills the queue from inside a listener, so it cannot make room itself
+	published := make(chan struct{})
+	bus.ListenAsync("user.registered", func(ctx context.Context, e Event) error {
+		for i := 0; i < 5; i++ {
+			bus.Publish(ctx, loginFailed{})
+		}
+		close(published)
+		return nil
+	})
+
+	bus.Publish(context.Background(), userRegistered{})
+
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("bus deadlocked publishing from a listener")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := bus.Close(ctx); err != nil {
+		t.Fatal("bus deadlocked:", err)
+	}
+
+	if count.Load() != 5 {
+		t.Error("expected 5 events to be handled, got", count.Load())
+	}
+}
+
+func TestBus_CloseTimeout(t *testing.T) {
+	bus := New(testLogger, 1, 1)
+
+	release := make(chan struct{})
+	defer close(release)
+	bus.ListenAsync("user.registered", func(ctx context.Context, e Event) error {
+		<-release
+		return nil
+	})
+	bus.Publish(context.Background(), userRegistered{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := bus.Close(ctx); err == nil {
+		t.Error("expected an error closing a bus with a listener still running")
+	}
+}
+
+func TestBus_Nil(t *testing.T) {
+	var bus *Bus
+
+	// a nil bus discards events
+	bus.Publish(context.Background(), userRegistered{})
+
+	if err := bus.Close(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,20 @@
+package events
+
+import (
+	"io"
+	"log/slog"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+type userRegistered struct {
+	Email string
+}
+
+func (userRegistered) Name() string { return "user.registered" }
+
+type loginFailed struct {
+	Email string
+}
+
+func (loginFailed) Name() string { return "user.login_failed" }
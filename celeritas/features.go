@@ -0,0 +1,57 @@
+package celeritas
+
+import (
+	"github.com/fouched/celeritas/cache"
+	"github.com/fouched/celeritas/features"
+	"net/http"
+)
+
+// createFeatures creates the feature flag manager, which keeps flags in the database when
+// FEATURES_STORE is database, and caches them in the application cache, or in memory when
+// there is no cache
+func (c *Celeritas) createFeatures() *features.Manager {
+	m := &features.Manager{
+		Cache:       c.Cache,
+		TTL:         seconds(c.config.Features.CacheTTL),
+		Environment: c.config.Environment,
+		Logger:      c.Logger,
+		UserID: func(r *http.Request) (userID int) {
+			// requests that did not go through SessionLoad are treated as guests
+			defer func() {
+				if recover() != nil {
+					userID = 0
+				}
+			}()
+			return c.Session.GetInt(r.Context(), "userID")
+		},
+	}
+
+	if m.Cache == nil {
+		if c.memoryCache == nil {
+			c.memoryCache = cache.NewMemoryCache()
+		}
+		m.Cache = c.memoryCache
+	}
+
+	if c.config.Features.Store == "database" {
+		m.Store = &features.DatabaseStore{DB: c.DB.Pool, Type: c.DB.Type}
+	}
+
+	return m
+}
+
+// Feature returns the feature flag called name, e.g.
+//
+//	if a.App.Feature("new-checkout").Enabled(r) { ... }
+func (c *Celeritas) Feature(name string) *features.Feature {
+	return c.Features.Feature(name)
+}
+
+// featureFunc returns the feature template function for the request, e.g.
+//
+//	{{ if feature("new-checkout") }} ... {{ end }}
+func (c *Celeritas) featureFunc(r *http.Request) interface{} {
+	return func(name string) bool {
+		return c.Feature(name).Enabled(r)
+	}
+}
@@ -0,0 +1,136 @@
+package features
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DatabaseStore keeps flags in the feature_flags table, which is created with
+// `celeritas make features`. Users and environments are stored as JSON
+type DatabaseStore struct {
+	DB   *sql.DB
+	Type string // the database type, e.g. postgres or mysql
+}
+
+const flagColumns = "name, description, enabled, percentage, users, environments"
+
+// rebind replaces ? placeholders with $1, $2, ... for postgres
+func (s *DatabaseStore) rebind(query string) string {
+	if s.Type != "postgres" && s.Type != "postgresql" {
+		return query
+	}
+
+	var sb strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			sb.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanFlag(row scanner) (*Flag, error) {
+	var f Flag
+	var enabled int
+	var users, environments string
+
+	err := row.Scan(&f.Name, &f.Description, &enabled, &f.Percentage, &users, &environments)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Enabled = enabled == 1
+
+	if users != "" {
+		if err := json.Unmarshal([]byte(users), &f.Users); err != nil {
+			return nil, err
+		}
+	}
+
+	if environments != "" {
+		if err := json.Unmarshal([]byte(environments), &f.Environments); err != nil {
+			return nil, err
+		}
+	}
+
+	return &f, nil
+}
+
+func (s *DatabaseStore) All() ([]Flag, error) {
+	rows, err := s.DB.Query("select " + flagColumns + " from feature_flags order by name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var flags []Flag
+	for rows.Next() {
+		f, err := scanFlag(rows)
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, *f)
+	}
+
+	return flags, rows.Err()
+}
+
+func (s *DatabaseStore) Get(name string) (*Flag, error) {
+	row := s.DB.QueryRow(s.rebind("select "+flagColumns+" from feature_flags where name = ?"), name)
+
+	f, err := scanFlag(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	return f, err
+}
+
+func (s *DatabaseStore) Save(f Flag) error {
+	users, err := json.Marshal(f.Users)
+	if err != nil {
+		return err
+	}
+
+	environments, err := json.Marshal(f.Environments)
+	if err != nil {
+		return err
+	}
+
+	enabled := 0
+	if f.Enabled {
+		enabled = 1
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(s.rebind("delete from feature_flags where name = ?"), f.Name)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(s.rebind("insert into feature_flags ("+flagColumns+", updated_at) values (?, ?, ?, ?, ?, ?, ?)"),
+		f.Name, f.Description, enabled, f.Percentage, string(users), string(environments), time.Now().UnixMilli())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
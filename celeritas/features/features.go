@@ -0,0 +1,302 @@
+package features
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fouched/celeritas/cache"
+	"hash/fnv"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Flag turns a feature on or off. A flag is on for a user when it is enabled in the current
+// environment, the user is one of Users, or the user falls in the Percentage of users it is
+// rolled out to
+type Flag struct {
+	Name         string          `json:"name"`
+	Description  string          `json:"description"`
+	Enabled      bool            `json:"enabled"`
+	Percentage   int             `json:"percentage"`   // 0 to 100; every user is always in, or out of, the same rollout
+	Users        []int           `json:"users"`        // ids of the users the flag is always on for
+	Environments map[string]bool `json:"environments"` // overrides Enabled per environment, e.g. "production": false
+}
+
+// Store keeps flags outside the code, e.g. in the feature_flags table, so they can be
+// changed without a deploy. Get returns nil if there is no flag called name
+type Store interface {
+	All() ([]Flag, error)
+	Get(name string) (*Flag, error)
+	Save(flag Flag) error
+}
+
+// ErrNoStore is returned when changing a flag without a store to save it in
+var ErrNoStore = errors.New("feature flags can only be changed when they are stored in the database, set FEATURES_STORE=database")
+
+// ErrUnknownFlag is returned when changing a flag that is neither defined nor stored
+var ErrUnknownFlag = errors.New("unknown feature flag")
+
+// Manager evaluates flags defined in code with Define, and flags in Store, which take
+// precedence. Flags read from the store are cached in Cache for TTL seconds
+type Manager struct {
+	Store       Store       // optional
+	Cache       cache.Cache // optional
+	TTL         int
+	Environment string                    // e.g. production
+	UserID      func(r *http.Request) int // the id of the signed in user, or 0
+	Logger      *slog.Logger
+
+	mu      sync.RWMutex
+	defined map[string]Flag
+}
+
+// Define adds flags to the manager, replacing flags with the same name
+func (m *Manager) Define(flags ...Flag) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.defined == nil {
+		m.defined = make(map[string]Flag)
+	}
+
+	for _, f := range flags {
+		m.defined[f.Name] = f
+	}
+}
+
+// cached is a flag as it is kept in the cache, including the fact that it is not in the store
+type cached struct {
+	Found bool `json:"found"`
+	Flag  Flag `json:"flag"`
+}
+
+func cacheKey(name string) string {
+	return "features:flag:" + name
+}
+
+// Flag returns the flag called name from the store, or as defined in code, and reports
+// whether there is one
+func (m *Manager) Flag(name string) (Flag, bool, error) {
+	stored, err := m.stored(name)
+	if err != nil {
+		return Flag{}, false, err
+	}
+
+	if stored != nil {
+		return *stored, true, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	f, ok := m.defined[name]
+	return f, ok, nil
+}
+
+// stored returns the flag called name from the cache or the store, or nil if it is not stored
+func (m *Manager) stored(name string) (*Flag, error) {
+	if m.Store == nil {
+		return nil, nil
+	}
+
+	if m.Cache != nil {
+		if value, err := m.Cache.Get(cacheKey(name)); err == nil {
+			if s, ok := value.(string); ok {
+				var c cached
+				if err := json.Unmarshal([]byte(s), &c); err == nil {
+					if !c.Found {
+						return nil, nil
+					}
+					return &c.Flag, nil
+				}
+			}
+		}
+	}
+
+	f, err := m.Store.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Cache != nil {
+		c := cached{Found: f != nil}
+		if f != nil {
+			c.Flag = *f
+		}
+
+		data, err := json.Marshal(c)
+		if err == nil {
+			err = m.Cache.Set(cacheKey(name), string(data), m.TTL)
+		}
+		if err != nil {
+			m.logger().Error("could not cache feature flag", "flag", name, "error", err)
+		}
+	}
+
+	return f, nil
+}
+
+// Flags returns every flag defined in code or stored, sorted by name
+func (m *Manager) Flags() ([]Flag, error) {
+	byName := make(map[string]Flag)
+
+	m.mu.RLock()
+	for name, f := range m.defined {
+		byName[name] = f
+	}
+	m.mu.RUnlock()
+
+	if m.Store != nil {
+		stored, err := m.Store.All()
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range stored {
+			byName[f.Name] = f
+		}
+	}
+
+	flags := make([]Flag, 0, len(byName))
+	for _, f := range byName {
+		flags = append(flags, f)
+	}
+
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i].Name < flags[j].Name
+	})
+
+	return flags, nil
+}
+
+// Save stores flag, overriding its definition in code
+func (m *Manager) Save(flag Flag) error {
+	if m.Store == nil {
+		return ErrNoStore
+	}
+
+	err := m.Store.Save(flag)
+	if err != nil {
+		return err
+	}
+
+	if m.Cache != nil {
+		return m.Cache.Forget(cacheKey(flag.Name))
+	}
+
+	return nil
+}
+
+// Toggle turns the flag called name on or off for everyone, by storing a copy of it with
+// Enabled set, and the override for the current environment removed
+func (m *Manager) Toggle(name string, enabled bool) error {
+	f, ok, err := m.Flag(name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownFlag, name)
+	}
+
+	f.Enabled = enabled
+	if _, overridden := f.Environments[m.Environment]; overridden {
+		envs := make(map[string]bool)
+		for env, on := range f.Environments {
+			if env != m.Environment {
+				envs[env] = on
+			}
+		}
+		f.Environments = envs
+	}
+
+	return m.Save(f)
+}
+
+// Feature returns the feature called name, to check whether it is on
+func (m *Manager) Feature(name string) *Feature {
+	return &Feature{manager: m, name: name}
+}
+
+// EnabledFor reports whether the flag is on for the user with userID, which is 0 for guests.
+// Flags that do not exist are off
+func (m *Manager) EnabledFor(f Flag, userID int) bool {
+	enabled := f.Enabled
+	if on, ok := f.Environments[m.Environment]; ok {
+		enabled = on
+	}
+
+	if enabled {
+		return true
+	}
+
+	if userID == 0 {
+		return false
+	}
+
+	if slices.Contains(f.Users, userID) {
+		return true
+	}
+
+	return f.Percentage > 0 && bucket(f.Name, userID) < f.Percentage
+}
+
+// bucket places a user in one of 100 buckets for a flag, so that the same users get the
+// flag as its percentage grows, while different flags are rolled out to different users
+func bucket(name string, userID int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name + ":" + strconv.Itoa(userID)))
+	return int(h.Sum32() % 100)
+}
+
+func (m *Manager) logger() *slog.Logger {
+	if m.Logger == nil {
+		return slog.Default()
+	}
+	return m.Logger
+}
+
+// Feature is a flag that can be checked for a request or a user
+type Feature struct {
+	manager *Manager
+	name    string
+}
+
+// Enabled reports whether the feature is on for the user making the request
+func (f *Feature) Enabled(r *http.Request) bool {
+	userID := 0
+	if f.manager.UserID != nil {
+		userID = f.manager.UserID(r)
+	}
+
+	return f.EnabledFor(userID)
+}
+
+// EnabledFor reports whether the feature is on for the user with userID, which is 0 for guests.
+// If the flag cannot be read from the store, the feature is off
+func (f *Feature) EnabledFor(userID int) bool {
+	flag, ok, err := f.manager.Flag(f.name)
+	if err != nil {
+		f.manager.logger().Error("could not read feature flag", "flag", f.name, "error", err)
+		return false
+	}
+
+	return ok && f.manager.EnabledFor(flag, userID)
+}
+
+// Middleware responds with 404 Not Found while the feature is off for the user, so routes
+// behind it do not appear to exist, e.g.
+//
+//	a.App.Routes.With(a.App.Feature("new-checkout").Middleware).Get("/checkout", a.Handlers.Checkout)
+func (f *Feature) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !f.Enabled(r) {
+			http.NotFound(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
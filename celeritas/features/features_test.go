@@ -0,0 +1,145 @@
+package features
+
+import (
+	"database/sql"
+	"github.com/fouched/celeritas/cache"
+	_ "modernc.org/sqlite"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+var enabledTests = []struct {
+	name     string
+	flag     Flag
+	userID   int
+	expected bool
+}{
+	{"off", Flag{Name: "a"}, 1, false},
+	{"on", Flag{Name: "a", Enabled: true}, 0, true},
+	{"off-in-environment", Flag{Name: "a", Enabled: true, Environments: map[string]bool{"production": false}}, 1, false},
+	{"on-in-environment", Flag{Name: "a", Environments: map[string]bool{"production": true}}, 0, true},
+	{"other-environment", Flag{Name: "a", Environments: map[string]bool{"staging": true}}, 1, false},
+	{"user", Flag{Name: "a", Users: []int{1, 2}}, 2, true},
+	{"other-user", Flag{Name: "a", Users: []int{1, 2}}, 3, false},
+	{"full-rollout", Flag{Name: "a", Percentage: 100}, 3, true},
+	{"rollout-guest", Flag{Name: "a", Percentage: 100}, 0, false},
+}
+
+func TestManager_EnabledFor(t *testing.T) {
+	m := &Manager{Environment: "production"}
+
+	for _, e := range enabledTests {
+		if got := m.EnabledFor(e.flag, e.userID); got != e.expected {
+			t.Errorf("%s: expected %t, got %t", e.name, e.expected, got)
+		}
+	}
+}
+
+func TestManager_Rollout(t *testing.T) {
+	m := &Manager{}
+	flag := Flag{Name: "rollout", Percentage: 30}
+
+	on := 0
+	for userID := 1; userID <= 1000; userID++ {
+		if m.EnabledFor(flag, userID) {
+			on++
+			if !m.EnabledFor(Flag{Name: "rollout", Percentage: 60}, userID) {
+				t.Fatal("expected users to keep the flag as the rollout grows")
+			}
+		}
+	}
+
+	if on < 250 || on > 350 {
+		t.Error("expected about 30% of users to get the flag, got", on)
+	}
+}
+
+func TestFeature_Middleware(t *testing.T) {
+	m := &Manager{UserID: func(r *http.Request) int { return 7 }}
+	m.Define(Flag{Name: "beta", Users: []int{7}}, Flag{Name: "secret"})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	w := httptest.NewRecorder()
+	m.Feature("beta").Middleware(handler).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusOK {
+		t.Error("expected the feature to be on for the user, got", w.Code)
+	}
+
+	for _, name := range []string{"secret", "undefined"} {
+		w = httptest.NewRecorder()
+		m.Feature(name).Middleware(handler).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", name, w.Code)
+		}
+	}
+}
+
+func TestManager_DatabaseStore(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "features.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`create table feature_flags (name text primary key, description text not null,
+		enabled integer not null, percentage integer not null, users text not null, environments text not null,
+		updated_at integer not null)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &Manager{
+		Store:       &DatabaseStore{DB: db, Type: "sqlite"},
+		Cache:       cache.NewMemoryCache(),
+		TTL:         60,
+		Environment: "production",
+	}
+	m.Define(Flag{Name: "search", Description: "new search", Environments: map[string]bool{"production": false}})
+
+	if m.Feature("search").EnabledFor(0) {
+		t.Error("expected the flag defined in code to be off")
+	}
+
+	err = m.Toggle("search", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !m.Feature("search").EnabledFor(0) {
+		t.Error("expected the stored flag to override the code, and the cache to be cleared")
+	}
+
+	flags, err := m.Flags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flags) != 1 || flags[0].Description != "new search" || len(flags[0].Environments) != 0 {
+		t.Errorf("wrong flags %+v", flags)
+	}
+
+	// changes made directly in the database are seen once the cache expires
+	_, err = db.Exec("update feature_flags set enabled = 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Feature("search").EnabledFor(0) {
+		t.Error("expected the flag to be cached")
+	}
+
+	err = m.Toggle("missing", true)
+	if err == nil {
+		t.Error("expected an error toggling an unknown flag")
+	}
+}
+
+func TestManager_ToggleWithoutStore(t *testing.T) {
+	m := &Manager{}
+	m.Define(Flag{Name: "a"})
+
+	if err := m.Toggle("a", true); err != ErrNoStore {
+		t.Error("expected ErrNoStore, got", err)
+	}
+}
@@ -0,0 +1,29 @@
+package celeritas
+
+import (
+	"github.com/alexedwards/scs/v2"
+	"github.com/fouched/celeritas/features"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCeleritas_Feature(t *testing.T) {
+	cfg, _ := ReadConfig(map[string]string{"APP_ENV": "staging"})
+	c := Celeritas{config: cfg, Session: scs.New()}
+	c.Features = c.createFeatures()
+	c.Features.Define(
+		features.Flag{Name: "search", Environments: map[string]bool{"staging": true}},
+		features.Flag{Name: "checkout", Enabled: true, Environments: map[string]bool{"staging": false}},
+	)
+
+	// without a session, the request is made by a guest
+	r := httptest.NewRequest("GET", "/", nil)
+	if !c.Feature("search").Enabled(r) {
+		t.Error("expected search to be on in staging")
+	}
+
+	feature := c.featureFunc(r).(func(string) bool)
+	if feature("checkout") {
+		t.Error("expected checkout to be off in staging")
+	}
+}
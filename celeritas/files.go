@@ -0,0 +1,35 @@
+package celeritas
+
+import (
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// files returns the directory dir of the application, i.e. views, mail, lang or public, from
+// Files when it is set, so that a deploy only needs the binary, e.g.
+//
+//	//go:embed views mail lang public
+//	var files embed.FS
+//
+//	cel := &celeritas.Celeritas{Files: files}
+//
+// In Debug mode dir is always read from disk, so that changes show without a rebuild
+func (c *Celeritas) files(dir string) fs.FS {
+	if c.Files != nil && !c.Debug {
+		sub, err := fs.Sub(c.Files, dir)
+		if err == nil {
+			return sub
+		}
+	}
+
+	return os.DirFS(filepath.Join(c.RootPath, dir))
+}
+
+// PublicFiles serves the public directory, e.g.
+//
+//	a.App.Routes.Handle("/public/*", http.StripPrefix("/public", a.App.PublicFiles()))
+func (c *Celeritas) PublicFiles() http.Handler {
+	return http.FileServer(http.FS(c.files("public")))
+}
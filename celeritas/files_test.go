@@ -0,0 +1,59 @@
+package celeritas
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCeleritas_Files(t *testing.T) {
+	root := t.TempDir()
+	err := os.MkdirAll(filepath.Join(root, "views"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(root, "views", "home.jet"), []byte("from disk"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Celeritas{RootPath: root, Files: fstest.MapFS{"views/home.jet": {Data: []byte("embedded")}}}
+
+	content, err := fs.ReadFile(c.files("views"), "home.jet")
+	if err != nil || string(content) != "embedded" {
+		t.Errorf("expected the embedded file, got %q %v", content, err)
+	}
+
+	c.Debug = true
+	content, err = fs.ReadFile(c.files("views"), "home.jet")
+	if err != nil || string(content) != "from disk" {
+		t.Errorf("expected the file on disk in debug mode, got %q %v", content, err)
+	}
+
+	c = &Celeritas{RootPath: root}
+	content, err = fs.ReadFile(c.files("views"), "home.jet")
+	if err != nil || string(content) != "from disk" {
+		t.Errorf("expected the file on disk without Files, got %q %v", content, err)
+	}
+}
+
+func TestCeleritas_PublicFiles(t *testing.T) {
+	c := &Celeritas{RootPath: t.TempDir(), Files: fstest.MapFS{"public/css/app.css": {Data: []byte("body {}")}}}
+	handler := http.StripPrefix("/public", c.PublicFiles())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/public/css/app.css", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "body {}" {
+		t.Errorf("expected the embedded file, got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/public/css/missing.css", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
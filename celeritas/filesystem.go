@@ -0,0 +1,131 @@
+package celeritas
+
+import (
+	"errors"
+	"github.com/fouched/celeritas/filesystem"
+	"github.com/fouched/celeritas/logger"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// createDisks creates the disks configured by FILESYSTEM_DISKS. Temporary URLs of local disks
+// are signed with the application key
+func (c *Celeritas) createDisks() (*filesystem.Disks, error) {
+	disks := &filesystem.Disks{Default: c.config.Filesystem.Default}
+
+	for name, cfg := range c.config.Filesystem.Disks {
+		switch cfg.Driver {
+		case "s3":
+			disk, err := filesystem.NewS3(filesystem.S3Options{
+				Endpoint: cfg.Endpoint,
+				Region:   cfg.Region,
+				Bucket:   cfg.Bucket,
+				Key:      cfg.Key,
+				Secret:   cfg.Secret,
+			})
+			if err != nil {
+				return nil, err
+			}
+			disks.Add(name, disk)
+		default:
+			disks.Add(name, &filesystem.Local{Root: cfg.Root, URL: cfg.URL, Secret: []byte(c.config.Key)})
+		}
+	}
+
+	return disks, nil
+}
+
+// Disk returns the disk called name, or the default disk if name is empty, e.g.
+//
+//	disk, err := a.App.Disk("s3")
+func (c *Celeritas) Disk(name string) (filesystem.FS, error) {
+	return c.Disks.Disk(name)
+}
+
+// DownloadFromDisk streams the file called name from a disk to the client as an attachment
+func (c *Celeritas) DownloadFromDisk(w http.ResponseWriter, r *http.Request, disk, name string) error {
+	d, err := c.Disk(disk)
+	if err != nil {
+		c.Error500(w)
+		return err
+	}
+
+	return c.serveFile(w, r, d, name, "attachment")
+}
+
+// serveFile streams the file called name from disk, answering range requests, or responds with
+// 404 Not Found if there is no such file. The disposition is attachment or inline
+func (c *Celeritas) serveFile(w http.ResponseWriter, r *http.Request, disk filesystem.FS, name, disposition string) error {
+	f, err := disk.Get(r.Context(), name)
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, filesystem.ErrInvalidName) {
+		c.Error404(w)
+		return err
+	} else if err != nil {
+		c.Error500(w)
+		return err
+	}
+	defer f.Close()
+
+	if f.ContentType != "" {
+		w.Header().Set("Content-Type", f.ContentType)
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": path.Base(f.Name)}))
+
+	if rs, ok := f.ReadCloser.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, f.Name, f.ModTime, rs)
+		return nil
+	}
+
+	if !f.ModTime.IsZero() {
+		w.Header().Set("Last-Modified", f.ModTime.UTC().Format(http.TimeFormat))
+	}
+	if f.Size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(f.Size, 10))
+	}
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// StorageURLs serves the temporary URLs of local disks, which are /storage/<disk>/<name>
+// unless DISK_<NAME>_URL points elsewhere. Requests without a valid signature are forbidden
+func (c *Celeritas) StorageURLs(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, "/storage/")
+		if !ok || c.Disks == nil || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		name, file, _ := strings.Cut(rest, "/")
+		if name == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		disk, err := c.Disks.Disk(name)
+		local, isLocal := disk.(*filesystem.Local)
+		if err != nil || !isLocal {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !local.Verify(file, r.URL.Query()) {
+			c.ErrorForbidden(w)
+			return
+		}
+
+		// the url expires, so shared caches must not keep the file
+		w.Header().Set("Cache-Control", "private")
+
+		err = c.serveFile(w, r, local, file, "inline")
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			logger.FromContext(r.Context()).Error("could not serve file", "disk", name, "file", file, "error", err)
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FS stores files on a disk, e.g. a directory on the local disk or an S3 bucket. Names are
+// slash separated paths relative to the root of the disk, e.g. avatars/1.png
+type FS interface {
+	// Put stores the contents of body as name, replacing the file if it exists
+	Put(ctx context.Context, name string, body io.Reader, opts PutOptions) error
+	// Get opens name for reading. The file must be closed. If there is no such file, the
+	// error wraps fs.ErrNotExist
+	Get(ctx context.Context, name string) (*File, error)
+	// Delete removes files, ignoring files that do not exist
+	Delete(ctx context.Context, names ...string) error
+	// List returns the files whose names start with prefix, e.g. avatars/, in any directory
+	// below it, sorted by name
+	List(ctx context.Context, prefix string) ([]Info, error)
+	// Exists reports whether there is a file called name
+	Exists(ctx context.Context, name string) (bool, error)
+	// TemporaryURL returns a URL that anyone can download name from until it expires
+	TemporaryURL(ctx context.Context, name string, expires time.Duration) (string, error)
+}
+
+// PutOptions describe a file being stored
+type PutOptions struct {
+	ContentType string            // detected from the extension of the name when empty
+	Metadata    map[string]string // stored with the file by drivers that support it, e.g. S3
+}
+
+// Info describes a stored file
+type Info struct {
+	Name        string
+	Size        int64
+	ModTime     time.Time
+	ContentType string
+	Metadata    map[string]string // only returned by Get
+}
+
+// File is a stored file opened for reading. The reader of files on the local disk and in S3
+// is also an io.Seeker, so that http.ServeContent can answer range requests
+type File struct {
+	io.ReadCloser
+	Info
+}
+
+// ErrInvalidName is returned for names that are empty or point outside the disk
+var ErrInvalidName = errors.New("invalid file name")
+
+// cleanName turns name into a path relative to the root of a disk, rejecting names that
+// would point outside it
+func cleanName(name string) (string, error) {
+	if strings.Contains(name, "\\") || strings.ContainsRune(name, 0) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("%w: %q", ErrInvalidName, name)
+		}
+	}
+
+	clean := strings.TrimPrefix(path.Clean("/"+name), "/")
+	if clean == "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+
+	return clean, nil
+}
+
+// notExist wraps fs.ErrNotExist, naming the file that was not found
+func notExist(name string) error {
+	return &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// Disks are the named disks of an application, e.g. local and s3
+type Disks struct {
+	Default string // the disk used when no name is given
+
+	mu    sync.RWMutex
+	disks map[string]FS
+}
+
+// Add adds a disk called name, replacing the disk with the same name
+func (d *Disks) Add(name string, disk FS) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.disks == nil {
+		d.disks = make(map[string]FS)
+	}
+	d.disks[name] = disk
+}
+
+// Disk returns the disk called name, or the default disk if name is empty
+func (d *Disks) Disk(name string) (FS, error) {
+	if name == "" {
+		name = d.Default
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	disk, ok := d.disks[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown disk %q", name)
+	}
+
+	return disk, nil
+}
+
+// Names returns the names of the disks, sorted
+func (d *Disks) Names() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	names := make([]string, 0, len(d.disks))
+	for name := range d.disks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
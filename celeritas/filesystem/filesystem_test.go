@@ -0,0 +1,130 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testDisk checks the behaviour every driver shares
+func testDisk(t *testing.T, disk FS) {
+	ctx := context.Background()
+
+	files := map[string]string{
+		"avatars/1.png":       "one",
+		"avatars/2024/2.png":  "two",
+		"documents/terms.txt": "terms",
+	}
+	for name, content := range files {
+		err := disk.Put(ctx, name, strings.NewReader(content), PutOptions{Metadata: map[string]string{"Owner": "1"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := disk.Get(ctx, "/avatars/1.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(f)
+	_ = f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "one" || f.Size != 3 || f.ContentType != "image/png" || f.Name != "avatars/1.png" {
+		t.Errorf("wrong file %q %+v", data, f.Info)
+	}
+	if _, ok := f.ReadCloser.(io.Seeker); !ok {
+		t.Error("expected the file to be seekable")
+	}
+
+	_, err = disk.Get(ctx, "avatars/3.png")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+
+	list, err := disk.List(ctx, "avatars/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Name != "avatars/1.png" || list[1].Name != "avatars/2024/2.png" {
+		t.Errorf("wrong listing %+v", list)
+	}
+
+	u, err := disk.TemporaryURL(ctx, "documents/terms.txt", time.Minute)
+	if err != nil || u == "" {
+		t.Errorf("expected a temporary url, got %q %v", u, err)
+	}
+
+	err = disk.Delete(ctx, "avatars/1.png", "avatars/3.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, expected := range map[string]bool{"avatars/1.png": false, "avatars/2024/2.png": true, "avatars": false} {
+		exists, err := disk.Exists(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exists != expected {
+			t.Errorf("%s: expected exists to be %v", name, expected)
+		}
+	}
+
+	for _, name := range []string{"../secret", "avatars/../../secret", "", "/"} {
+		if _, err := disk.Get(ctx, name); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("%q: expected ErrInvalidName, got %v", name, err)
+		}
+	}
+}
+
+func TestS3_Disk(t *testing.T) {
+	if s3Disk == nil {
+		t.Skip("docker is not available")
+	}
+
+	testDisk(t, s3Disk)
+
+	u, err := s3Disk.TemporaryURL(context.Background(), "documents/terms.txt", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "terms" {
+		t.Errorf("expected the temporary url to serve the file, got %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestDisks_Disk(t *testing.T) {
+	d := Disks{Default: "local"}
+	d.Add("local", &Local{Root: t.TempDir()})
+	d.Add("s3", &S3{Bucket: "test"})
+
+	disk, err := d.Disk("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := disk.(*Local); !ok {
+		t.Error("expected the default disk")
+	}
+
+	if _, err := d.Disk("ftp"); err == nil {
+		t.Error("expected an error for an unknown disk")
+	}
+
+	if strings.Join(d.Names(), ",") != "local,s3" {
+		t.Errorf("wrong names %v", d.Names())
+	}
+}
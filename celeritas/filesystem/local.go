@@ -0,0 +1,236 @@
+package filesystem
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Local stores files in a directory on the local disk. Temporary URLs point to URL, and are
+// signed with Secret, so that the application can check them with Verify before serving the file
+type Local struct {
+	Root   string
+	URL    string // where the files of the disk are served, e.g. https://example.com/storage/local
+	Secret []byte
+}
+
+// path returns the path of the file called name on the local disk
+func (l *Local) path(name string) (string, string, error) {
+	clean, err := cleanName(name)
+	if err != nil {
+		return "", "", err
+	}
+
+	return clean, filepath.Join(l.Root, filepath.FromSlash(clean)), nil
+}
+
+func (l *Local) Put(ctx context.Context, name string, body io.Reader, opts PutOptions) error {
+	_, file, err := l.path(name)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(file), 0755)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so readers never see a half written file
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".put-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, body)
+	if err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(tmp.Name(), 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
+
+func (l *Local) Get(ctx context.Context, name string) (*File, error) {
+	clean, file, err := l.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, notExist(clean)
+	} else if err != nil {
+		return nil, err
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	if stat.IsDir() {
+		_ = f.Close()
+		return nil, notExist(clean)
+	}
+
+	return &File{ReadCloser: f, Info: localInfo(clean, stat)}, nil
+}
+
+func localInfo(name string, stat fs.FileInfo) Info {
+	return Info{
+		Name:        name,
+		Size:        stat.Size(),
+		ModTime:     stat.ModTime(),
+		ContentType: mime.TypeByExtension(path.Ext(name)),
+	}
+}
+
+func (l *Local) Delete(ctx context.Context, names ...string) error {
+	for _, name := range names {
+		_, file, err := l.path(name)
+		if err != nil {
+			return err
+		}
+
+		err = os.Remove(file)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *Local) List(ctx context.Context, prefix string) ([]Info, error) {
+	// only walk the directory the prefix is in
+	dir := filepath.Join(l.Root, filepath.FromSlash(path.Dir("/"+prefix)))
+
+	var files []Info
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && file == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".put-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.Root, file)
+		if err != nil {
+			return err
+		}
+
+		name := filepath.ToSlash(rel)
+		if !strings.HasPrefix(name, strings.TrimPrefix(prefix, "/")) {
+			return nil
+		}
+
+		stat, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		files = append(files, localInfo(name, stat))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+
+	return files, nil
+}
+
+func (l *Local) Exists(ctx context.Context, name string) (bool, error) {
+	_, file, err := l.path(name)
+	if err != nil {
+		return false, err
+	}
+
+	stat, err := os.Stat(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return !stat.IsDir(), nil
+}
+
+func (l *Local) TemporaryURL(ctx context.Context, name string, expires time.Duration) (string, error) {
+	if l.URL == "" || len(l.Secret) == 0 {
+		return "", errors.New("temporary URLs of a local disk need a URL and a secret")
+	}
+
+	clean, err := cleanName(name)
+	if err != nil {
+		return "", err
+	}
+
+	until := time.Now().Add(expires).Unix()
+	query := url.Values{
+		"expires":   {strconv.FormatInt(until, 10)},
+		"signature": {l.sign(clean, until)},
+	}
+
+	u := &url.URL{Path: "/" + clean}
+	return fmt.Sprintf("%s%s?%s", strings.TrimSuffix(l.URL, "/"), u.EscapedPath(), query.Encode()), nil
+}
+
+// Verify reports whether query holds a valid signature for name, from a temporary URL that
+// has not expired
+func (l *Local) Verify(name string, query url.Values) bool {
+	if len(l.Secret) == 0 {
+		return false
+	}
+
+	clean, err := cleanName(name)
+	if err != nil {
+		return false
+	}
+
+	until, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > until {
+		return false
+	}
+
+	return hmac.Equal([]byte(query.Get("signature")), []byte(l.sign(clean, until)))
+}
+
+func (l *Local) sign(name string, until int64) string {
+	mac := hmac.New(sha256.New, l.Secret)
+	mac.Write([]byte(name + "\n" + strconv.FormatInt(until, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,59 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocal_Disk(t *testing.T) {
+	testDisk(t, &Local{Root: t.TempDir(), URL: "https://example.com/storage/local", Secret: []byte("secret")})
+}
+
+func TestLocal_TemporaryURL(t *testing.T) {
+	l := &Local{Root: t.TempDir(), URL: "https://example.com/storage/local/", Secret: []byte("secret")}
+
+	raw, err := l.TemporaryURL(context.Background(), "reports/q1 2025.pdf", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Path != "/storage/local/reports/q1 2025.pdf" {
+		t.Errorf("wrong path %q", u.Path)
+	}
+
+	name := strings.TrimPrefix(u.Path, "/storage/local/")
+	if !l.Verify(name, u.Query()) {
+		t.Error("expected the url to be valid")
+	}
+
+	if l.Verify("reports/q2 2025.pdf", u.Query()) {
+		t.Error("expected the url to be invalid for another file")
+	}
+
+	other := &Local{Root: l.Root, URL: l.URL, Secret: []byte("other")}
+	if other.Verify(name, u.Query()) {
+		t.Error("expected the url to be invalid with another secret")
+	}
+
+	expired, err := l.TemporaryURL(context.Background(), name, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ = url.Parse(expired)
+	if l.Verify(name, u.Query()) {
+		t.Error("expected an expired url to be invalid")
+	}
+
+	_, err = (&Local{Root: l.Root}).TemporaryURL(context.Background(), name, time.Minute)
+	if err == nil {
+		t.Error("expected an error without a url and secret")
+	}
+}
@@ -0,0 +1,202 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// S3Options configure a disk in an S3 compatible object store, e.g. AWS S3 or MinIO
+type S3Options struct {
+	Endpoint string // e.g. https://s3.amazonaws.com or http://localhost:9000
+	Region   string
+	Bucket   string
+	Key      string
+	Secret   string
+}
+
+// S3 stores files as objects in a bucket of an S3 compatible object store
+type S3 struct {
+	Client *minio.Client
+	Bucket string
+}
+
+// NewS3 connects to the object store. The connection is checked by the first operation
+func NewS3(o S3Options) (*S3, error) {
+	if o.Bucket == "" {
+		return nil, errors.New("an S3 disk needs a bucket")
+	}
+
+	endpoint := o.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3.amazonaws.com"
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := minio.New(u.Host, &minio.Options{
+		Creds:  credentials.NewStaticV4(o.Key, o.Secret, ""),
+		Secure: u.Scheme == "https",
+		Region: o.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3{Client: client, Bucket: o.Bucket}, nil
+}
+
+// isNotFound reports whether err means that the object does not exist
+func isNotFound(err error) bool {
+	code := minio.ToErrorResponse(err).Code
+	return code == "NoSuchKey" || code == "NotFound"
+}
+
+func (s *S3) Put(ctx context.Context, name string, body io.Reader, opts PutOptions) error {
+	clean, err := cleanName(name)
+	if err != nil {
+		return err
+	}
+
+	contentType := opts.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(clean))
+	}
+
+	_, err = s.Client.PutObject(ctx, s.Bucket, clean, body, size(body), minio.PutObjectOptions{
+		ContentType:  contentType,
+		UserMetadata: opts.Metadata,
+	})
+	return err
+}
+
+// size returns the size of body when it is known, so that small files are not uploaded in
+// parts, or -1
+func size(body io.Reader) int64 {
+	switch b := body.(type) {
+	case interface{ Size() int64 }:
+		return b.Size()
+	case interface{ Len() int }:
+		return int64(b.Len())
+	case *os.File:
+		if stat, err := b.Stat(); err == nil && stat.Mode().IsRegular() {
+			offset, err := b.Seek(0, io.SeekCurrent)
+			if err == nil {
+				return stat.Size() - offset
+			}
+		}
+	}
+
+	return -1
+}
+
+func (s *S3) Get(ctx context.Context, name string) (*File, error) {
+	clean, err := cleanName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := s.Client.GetObject(ctx, s.Bucket, clean, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// the object is only requested when it is first read, or stat
+	stat, err := obj.Stat()
+	if err != nil {
+		_ = obj.Close()
+		if isNotFound(err) {
+			return nil, notExist(clean)
+		}
+		return nil, err
+	}
+
+	info := s3Info(stat)
+	info.Metadata = stat.UserMetadata
+
+	return &File{ReadCloser: obj, Info: info}, nil
+}
+
+func s3Info(o minio.ObjectInfo) Info {
+	return Info{
+		Name:        o.Key,
+		Size:        o.Size,
+		ModTime:     o.LastModified,
+		ContentType: o.ContentType,
+	}
+}
+
+func (s *S3) Delete(ctx context.Context, names ...string) error {
+	for _, name := range names {
+		clean, err := cleanName(name)
+		if err != nil {
+			return err
+		}
+
+		err = s.Client.RemoveObject(ctx, s.Bucket, clean, minio.RemoveObjectOptions{})
+		if err != nil && !isNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *S3) List(ctx context.Context, prefix string) ([]Info, error) {
+	var files []Info
+	for o := range s.Client.ListObjects(ctx, s.Bucket, minio.ListObjectsOptions{
+		Prefix:    strings.TrimPrefix(prefix, "/"),
+		Recursive: true,
+	}) {
+		if o.Err != nil {
+			return nil, o.Err
+		}
+		files = append(files, s3Info(o))
+	}
+
+	return files, nil
+}
+
+func (s *S3) Exists(ctx context.Context, name string) (bool, error) {
+	clean, err := cleanName(name)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = s.Client.StatObject(ctx, s.Bucket, clean, minio.StatObjectOptions{})
+	if isNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *S3) TemporaryURL(ctx context.Context, name string, expires time.Duration) (string, error) {
+	clean, err := cleanName(name)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := s.Client.PresignedGetObject(ctx, s.Bucket, clean, expires, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return u.String(), nil
+}
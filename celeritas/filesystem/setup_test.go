@@ -0,0 +1,77 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"github.com/minio/minio-go/v7"
+	"github.com/ory/dockertest/v3"
+	"log"
+	"os"
+	"testing"
+)
+
+// s3Disk is a bucket in a MinIO container, or nil when docker is not available, in which
+// case the S3 tests are skipped
+var s3Disk *S3
+
+func TestMain(m *testing.M) {
+	pool, resource := startMinIO()
+
+	code := m.Run()
+
+	if resource != nil {
+		if err := pool.Purge(resource); err != nil {
+			log.Fatalf("could not purge resource: %s", err)
+		}
+	}
+
+	os.Exit(code)
+}
+
+func startMinIO() (*dockertest.Pool, *dockertest.Resource) {
+	pool, err := dockertest.NewPool("")
+	if err == nil {
+		err = pool.Client.Ping()
+	}
+	if err != nil {
+		log.Println("docker is not available, skipping the S3 tests:", err)
+		return nil, nil
+	}
+
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "minio/minio",
+		Tag:        "latest",
+		Cmd:        []string{"server", "/data"},
+		Env:        []string{"MINIO_ROOT_USER=celeritas", "MINIO_ROOT_PASSWORD=celeritas-secret"},
+	})
+	if err != nil {
+		log.Fatal("could not start minio: ", err)
+	}
+
+	err = pool.Retry(func() error {
+		disk, err := NewS3(S3Options{
+			Endpoint: fmt.Sprintf("http://localhost:%s", resource.GetPort("9000/tcp")),
+			Region:   "us-east-1",
+			Bucket:   "celeritas",
+			Key:      "celeritas",
+			Secret:   "celeritas-secret",
+		})
+		if err != nil {
+			return err
+		}
+
+		err = disk.Client.MakeBucket(context.Background(), disk.Bucket, minio.MakeBucketOptions{})
+		if err != nil {
+			return err
+		}
+
+		s3Disk = disk
+		return nil
+	})
+	if err != nil {
+		_ = pool.Purge(resource)
+		log.Fatal("could not connect to minio: ", err)
+	}
+
+	return pool, resource
+}
@@ -0,0 +1,124 @@
+package celeritas
+
+import (
+	"context"
+	"github.com/fouched/celeritas/filesystem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newDiskCeleritas(t *testing.T) *Celeritas {
+	c := &Celeritas{}
+	c.config.Key = "abcdefghijklmnopqrstuvwxyz123456"
+	c.config.Filesystem = FilesystemConfig{
+		Default: "local",
+		Disks:   map[string]DiskConfig{"local": {Driver: "local", Root: t.TempDir(), URL: "https://example.com/storage/local"}},
+	}
+
+	disks, err := c.createDisks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Disks = disks
+
+	disk, _ := c.Disk("")
+	err = disk.Put(context.Background(), "reports/q1.txt", strings.NewReader("first quarter"), filesystem.PutOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+var downloadTests = []struct {
+	name     string
+	file     string
+	expected int
+}{
+	{"found", "reports/q1.txt", http.StatusOK},
+	{"missing", "reports/q2.txt", http.StatusNotFound},
+	{"outside-disk", "../../etc/passwd", http.StatusNotFound},
+}
+
+func TestCeleritas_DownloadFromDisk(t *testing.T) {
+	c := newDiskCeleritas(t)
+
+	for _, e := range downloadTests {
+		w := httptest.NewRecorder()
+		_ = c.DownloadFromDisk(w, httptest.NewRequest("GET", "/download", nil), "local", e.file)
+
+		if w.Code != e.expected {
+			t.Errorf("%s: expected status %d, got %d", e.name, e.expected, w.Code)
+		}
+		if w.Code == http.StatusOK {
+			if w.Body.String() != "first quarter" || w.Header().Get("Content-Disposition") != `attachment; filename=q1.txt` {
+				t.Errorf("%s: wrong download %q %v", e.name, w.Body.String(), w.Header())
+			}
+		}
+	}
+
+	w := httptest.NewRecorder()
+	if err := c.DownloadFromDisk(w, httptest.NewRequest("GET", "/download", nil), "ftp", "reports/q1.txt"); err == nil {
+		t.Error("expected an error for an unknown disk")
+	}
+}
+
+func TestCeleritas_DownloadFileRange(t *testing.T) {
+	c := newDiskCeleritas(t)
+
+	r := httptest.NewRequest("GET", "/download", nil)
+	r.Header.Set("Range", "bytes=0-4")
+
+	w := httptest.NewRecorder()
+	err := c.DownloadFile(w, r, c.config.Filesystem.Disks["local"].Root, "reports/q1.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Code != http.StatusPartialContent || w.Body.String() != "first" {
+		t.Errorf("expected part of the file, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestCeleritas_StorageURLs(t *testing.T) {
+	c := newDiskCeleritas(t)
+	disk, _ := c.Disk("local")
+
+	handler := c.StorageURLs(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	raw, err := disk.TemporaryURL(context.Background(), "reports/q1.txt", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(raw)
+
+	tampered := *u
+	tampered.RawQuery = strings.Replace(u.RawQuery, "expires=", "expires=9", 1)
+
+	tests := []struct {
+		name     string
+		target   string
+		expected int
+	}{
+		{"valid", u.RequestURI(), http.StatusOK},
+		{"tampered", tampered.RequestURI(), http.StatusForbidden},
+		{"unsigned", "/storage/local/reports/q1.txt", http.StatusForbidden},
+		{"unknown-disk", "/storage/ftp/reports/q1.txt", http.StatusTeapot},
+		{"other-path", "/about", http.StatusTeapot},
+	}
+
+	for _, e := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", e.target, nil))
+
+		if w.Code != e.expected {
+			t.Errorf("%s: expected status %d, got %d", e.name, e.expected, w.Code)
+		}
+	}
+}
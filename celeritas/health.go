@@ -0,0 +1,213 @@
+package celeritas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gomodule/redigo/redis"
+	"net/http"
+	"time"
+)
+
+// healthCheckTimeout is how long /readyz waits for all checks to complete
+const healthCheckTimeout = 5 * time.Second
+
+// HealthCheck reports whether a backend the application depends on is available
+type HealthCheck func(ctx context.Context) error
+
+type namedHealthCheck struct {
+	name  string
+	check HealthCheck
+}
+
+// CheckResult is the outcome of a single readiness check
+type CheckResult struct {
+	Status  string `json:"status"`
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+// HealthReport is the JSON body returned by /healthz and /readyz
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// AddHealthCheck registers a check that must pass for /readyz to report the application
+// as ready. Registering a check with an existing name replaces it
+func (c *Celeritas) AddHealthCheck(name string, check HealthCheck) {
+	c.healthMu.Lock()
+	defer c.healthMu.Unlock()
+
+	for i, x := range c.healthChecks {
+		if x.name == name {
+			c.healthChecks[i].check = check
+			return
+		}
+	}
+
+	c.healthChecks = append(c.healthChecks, namedHealthCheck{name: name, check: check})
+}
+
+// addDefaultHealthChecks registers a readiness check for every configured backend
+func (c *Celeritas) addDefaultHealthChecks() {
+	if c.DB.Pool != nil {
+		c.AddHealthCheck("database", func(ctx context.Context) error {
+			return c.DB.Pool.PingContext(ctx)
+		})
+	}
+
+	if redisPool != nil {
+		c.AddHealthCheck("redis", func(ctx context.Context) error {
+			conn, err := redisPool.GetContext(ctx)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			_, err = redis.DoContext(conn, ctx, "PING")
+			return err
+		})
+	}
+
+	if badgerConn != nil {
+		c.AddHealthCheck("badger", func(ctx context.Context) error {
+			if badgerConn.IsClosed() {
+				return errors.New("badger database is closed")
+			}
+			return nil
+		})
+	}
+
+	if badgerJobsConn != nil {
+		c.AddHealthCheck("jobs", func(ctx context.Context) error {
+			if badgerJobsConn.IsClosed() {
+				return errors.New("badger jobs database is closed")
+			}
+			return nil
+		})
+	}
+
+	if c.config.Mail.HealthCheck && c.Mail.Host != "" {
+		c.AddHealthCheck("mail", c.Mail.Ping)
+	}
+}
+
+// Healthz is the liveness probe. It reports ok for as long as the server can handle requests
+func (c *Celeritas) Healthz(w http.ResponseWriter, r *http.Request) {
+	_ = c.WriteJSON(w, http.StatusOK, HealthReport{Status: "ok"})
+}
+
+// HealthProbes answers /healthz and /readyz ahead of the rest of the middleware, so probes
+// don't create sessions, are not subject to CSRF checks and keep reporting the server as live
+// in maintenance mode. It is middleware rather than a route because chi panics when middleware
+// is added to a router that has routes, and applications add their own middleware to Routes
+// after routes() returns
+func (c *Celeritas) HealthProbes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			switch r.URL.Path {
+			case "/healthz":
+				c.Healthz(w, r)
+				return
+			case "/readyz":
+				c.Readyz(w, r)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Readyz is the readiness probe. It runs all health checks concurrently and responds
+// with 503 Service Unavailable if any of them fail
+func (c *Celeritas) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := c.CheckHealth(r.Context())
+
+	status := http.StatusOK
+	if report.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+
+	_ = c.WriteJSON(w, status, report)
+}
+
+// CheckHealth runs all registered health checks and reports on each of them
+func (c *Celeritas) CheckHealth(ctx context.Context) HealthReport {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	c.healthMu.RLock()
+	checks := append([]namedHealthCheck{}, c.healthChecks...)
+	c.healthMu.RUnlock()
+
+	report := HealthReport{
+		Status: "ok",
+		Checks: make(map[string]CheckResult, len(checks)),
+	}
+
+	type outcome struct {
+		name   string
+		result CheckResult
+	}
+
+	// buffered, so checks that finish after the timeout don't block
+	outcomes := make(chan outcome, len(checks))
+	start := time.Now()
+
+	for _, x := range checks {
+		go func() {
+			start := time.Now()
+			err := runHealthCheck(ctx, x.check)
+			result := CheckResult{
+				Status:  "ok",
+				Latency: time.Since(start).String(),
+			}
+			if err != nil {
+				result.Status = "error"
+				result.Error = err.Error()
+			}
+
+			outcomes <- outcome{name: x.name, result: result}
+		}()
+	}
+
+	// don't wait for checks that ignore ctx beyond the timeout
+wait:
+	for range checks {
+		select {
+		case o := <-outcomes:
+			report.Checks[o.name] = o.result
+		case <-ctx.Done():
+			break wait
+		}
+	}
+
+	for _, x := range checks {
+		if _, ok := report.Checks[x.name]; !ok {
+			report.Checks[x.name] = CheckResult{
+				Status:  "error",
+				Latency: time.Since(start).String(),
+				Error:   "timed out",
+			}
+		}
+
+		if report.Checks[x.name].Status != "ok" {
+			report.Status = "error"
+		}
+	}
+
+	return report
+}
+
+// runHealthCheck calls check, turning a panic into an error so that it fails only that check
+func runHealthCheck(ctx context.Context, check HealthCheck) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return check(ctx)
+}
@@ -0,0 +1,121 @@
+package celeritas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCeleritas_Healthz(t *testing.T) {
+	var c Celeritas
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/healthz", nil)
+	c.Healthz(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Error("wrong status code; expected 200 but got", w.Code)
+	}
+}
+
+func TestCeleritas_Readyz(t *testing.T) {
+	var c Celeritas
+	c.AddHealthCheck("ok", func(ctx context.Context) error { return nil })
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/readyz", nil)
+	c.Readyz(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Error("wrong status code; expected 200 but got", w.Code)
+	}
+
+	c.AddHealthCheck("failing", func(ctx context.Context) error { return errors.New("down") })
+
+	w = httptest.NewRecorder()
+	c.Readyz(w, r)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Error("wrong status code; expected 503 but got", w.Code)
+	}
+
+	var report HealthReport
+	err := json.Unmarshal(w.Body.Bytes(), &report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Checks["ok"].Status != "ok" {
+		t.Error("passing check reported as", report.Checks["ok"].Status)
+	}
+
+	if report.Checks["failing"].Status != "error" || report.Checks["failing"].Error != "down" {
+		t.Error("failing check not reported correctly:", report.Checks["failing"])
+	}
+
+	if report.Checks["ok"].Latency == "" {
+		t.Error("latency not reported")
+	}
+}
+
+func TestCeleritas_CheckHealthPanic(t *testing.T) {
+	var c Celeritas
+	c.AddHealthCheck("ok", func(ctx context.Context) error { return nil })
+	c.AddHealthCheck("panics", func(ctx context.Context) error { panic("oops") })
+
+	report := c.CheckHealth(context.Background())
+
+	if report.Status != "error" || report.Checks["ok"].Status != "ok" {
+		t.Error("wrong report for a panicking check:", report)
+	}
+
+	if report.Checks["panics"].Status != "error" || report.Checks["panics"].Error != "panic: oops" {
+		t.Error("panic not reported as the check's error:", report.Checks["panics"])
+	}
+}
+
+func TestCeleritas_CheckHealthTimeout(t *testing.T) {
+	var c Celeritas
+	c.AddHealthCheck("ok", func(ctx context.Context) error { return nil })
+
+	// a check that ignores its context, like a dial without a deadline
+	block := make(chan struct{})
+	defer close(block)
+	c.AddHealthCheck("hangs", func(ctx context.Context) error {
+		<-block
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	done := make(chan HealthReport)
+	go func() { done <- c.CheckHealth(ctx) }()
+
+	select {
+	case report := <-done:
+		if report.Status != "error" || report.Checks["ok"].Status != "ok" {
+			t.Error("wrong report with a hanging check:", report)
+		}
+		if report.Checks["hangs"].Status != "error" || report.Checks["hangs"].Error != "timed out" {
+			t.Error("hanging check not reported as timed out:", report.Checks["hangs"])
+		}
+	case <-time.After(time.Second):
+		t.Fatal("CheckHealth waited for a check that ignores its context")
+	}
+}
+
+func TestCeleritas_AddHealthCheckReplaces(t *testing.T) {
+	var c Celeritas
+	c.AddHealthCheck("db", func(ctx context.Context) error { return errors.New("down") })
+	c.AddHealthCheck("db", func(ctx context.Context) error { return nil })
+
+	report := c.CheckHealth(context.Background())
+	if report.Status != "ok" || len(report.Checks) != 1 {
+		t.Error("registering a check twice should replace it:", report)
+	}
+}
@@ -0,0 +1,121 @@
+package celeritas
+
+import (
+	"github.com/fouched/celeritas/i18n"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// sessionLocale is the session key that remembers the locale chosen by a signed in user
+const sessionLocale = "locale"
+
+// createI18n loads the translations in lang/, which is optional
+func (c *Celeritas) createI18n() (*i18n.Bundle, error) {
+	return i18n.LoadFS(c.files("lang"), c.config.I18n.DefaultLocale)
+}
+
+// Localize finds the locale of the request, and stores it in the request context for the t
+// template function, i18n.T and validation messages. The locale is taken from, in order:
+//
+//   - the first path segment, e.g. /fr/about, which is removed before routing, when
+//     I18N_URL_PREFIX is true
+//   - the I18N_COOKIE cookie, set by SetLocale
+//   - the session, set by SetLocale
+//   - the Accept-Language header
+//   - I18N_DEFAULT_LOCALE
+//
+// Only locales with a catalog in lang/ are used
+func (c *Celeritas) Localize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.I18n == nil || len(c.I18n.Locales()) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		locale := ""
+		if !c.config.I18n.NoURLPrefix {
+			var path string
+			if locale, path = c.localePrefix(r.URL.Path); locale != "" {
+				u := *r.URL
+				u.Path = path
+				u.RawPath = ""
+				r = r.Clone(r.Context())
+				r.URL = &u
+			}
+		}
+
+		if locale == "" {
+			if cookie, err := r.Cookie(c.config.I18n.Cookie); err == nil {
+				locale, _ = c.I18n.Supported(cookie.Value)
+			}
+		}
+
+		if locale == "" {
+			locale, _ = c.I18n.Supported(c.sessionLocale(r))
+		}
+
+		if locale == "" {
+			locale, _ = c.I18n.Match(i18n.ParseAcceptLanguage(r.Header.Get("Accept-Language"))...)
+		}
+
+		if locale == "" {
+			locale = c.config.I18n.DefaultLocale
+		}
+
+		w.Header().Set("Content-Language", locale)
+		w.Header().Add("Vary", "Accept-Language")
+
+		next.ServeHTTP(w, r.WithContext(i18n.WithLocale(r.Context(), locale)))
+	})
+}
+
+// localePrefix returns the locale in the first segment of path, and the path without it, or an
+// empty locale if the segment is not a locale with a catalog
+func (c *Celeritas) localePrefix(path string) (string, string) {
+	segment, rest, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	locale, ok := c.I18n.Supported(segment)
+	if !ok {
+		return "", path
+	}
+
+	return locale, "/" + rest
+}
+
+// sessionLocale returns the locale in the session, or an empty string for requests that did
+// not go through SessionLoad
+func (c *Celeritas) sessionLocale(r *http.Request) (locale string) {
+	defer func() {
+		if recover() != nil {
+			locale = ""
+		}
+	}()
+	return c.Session.GetString(r.Context(), sessionLocale)
+}
+
+// SetLocale remembers the locale chosen by the user in a cookie, and in the session, e.g. in
+// a handler for a language switcher
+func (c *Celeritas) SetLocale(w http.ResponseWriter, r *http.Request, locale string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     c.config.I18n.Cookie,
+		Value:    locale,
+		Path:     "/",
+		Domain:   c.config.Cookie.Domain,
+		Expires:  time.Now().AddDate(1, 0, 0),
+		HttpOnly: true,
+		Secure:   c.config.Cookie.Secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	func() {
+		defer func() { _ = recover() }()
+		c.Session.Put(r.Context(), sessionLocale, locale)
+	}()
+}
+
+// T translates key into the locale of the request, e.g.
+//
+//	a.App.T(r, "auth.failed")
+func (c *Celeritas) T(r *http.Request, key string, args ...interface{}) string {
+	return c.I18n.T(r.Context(), key, args...)
+}
@@ -0,0 +1,50 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseAcceptLanguage returns the locales in an Accept-Language header, most preferred first,
+// e.g. "fr-CH, fr;q=0.9, en;q=0.8" gives fr-CH, fr and en. Locales with q=0 and * are left out
+func ParseAcceptLanguage(header string) []string {
+	type preference struct {
+		locale string
+		q      float64
+	}
+
+	var prefs []preference
+	for _, part := range strings.Split(header, ",") {
+		locale, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		locale = strings.TrimSpace(locale)
+		if locale == "" || locale == "*" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.TrimSpace(name) == "q" {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = f
+				}
+			}
+		}
+
+		if q > 0 {
+			prefs = append(prefs, preference{locale: locale, q: q})
+		}
+	}
+
+	sort.SliceStable(prefs, func(i, j int) bool {
+		return prefs[i].q > prefs[j].q
+	})
+
+	locales := make([]string, len(prefs))
+	for i, p := range prefs {
+		locales[i] = p.locale
+	}
+
+	return locales
+}
@@ -0,0 +1,351 @@
+package i18n
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Bundle holds the translations of every locale. Messages are looked up by a key, e.g.
+// "auth.failed", in the requested locale, then in its base language, e.g. pt for pt-BR, and
+// then in Fallback. A message that is not found anywhere is shown as its key
+type Bundle struct {
+	Fallback string
+
+	mu       sync.RWMutex
+	messages map[string]map[string]Message // by locale, in lower case, then key
+	locales  []string                      // as they were named, e.g. pt-BR
+}
+
+// Message is a translation, with a form per plural category, e.g. one and other. A message
+// that does not depend on a count only has the other form
+type Message map[string]string
+
+// pluralForms are the keys that make an object in a catalog a plural message, rather than a
+// group of keys
+var pluralForms = map[string]bool{"zero": true, "one": true, "two": true, "few": true, "many": true, "other": true}
+
+type contextKey struct{}
+
+// New creates an empty bundle that falls back to the fallback locale
+func New(fallback string) *Bundle {
+	return &Bundle{Fallback: fallback, messages: make(map[string]map[string]Message)}
+}
+
+// Load creates a bundle from the catalogs in dir. A catalog is a JSON or TOML file named
+// after its locale, e.g. lang/en.json or lang/pt-BR.toml, or any such file in a directory
+// named after the locale, e.g. lang/en/auth.json. Nested objects are flattened into dotted
+// keys, except objects with plural forms, e.g.
+//
+//	{"cart": {"items": {"one": "{count} item", "other": "{count} items"}}}
+func Load(dir, fallback string) (*Bundle, error) {
+	return LoadFS(os.DirFS(dir), fallback)
+}
+
+// LoadFS creates a bundle from the catalogs in fsys, e.g. the lang directory of an embed.FS,
+// in the same way as Load
+func LoadFS(fsys fs.FS, fallback string) (*Bundle, error) {
+	b := New(fallback)
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && name == "." {
+				return fs.SkipDir
+			}
+			return err
+		}
+
+		ext := path.Ext(name)
+		if d.IsDir() || (ext != ".json" && ext != ".toml") {
+			return nil
+		}
+
+		locale := strings.TrimSuffix(strings.Split(name, "/")[0], ext)
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		var catalog map[string]interface{}
+		if ext == ".json" {
+			err = json.Unmarshal(data, &catalog)
+		} else {
+			err = toml.Unmarshal(data, &catalog)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid catalog %s: %w", name, err)
+		}
+
+		b.Add(locale, catalog)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Add adds the messages in catalog to locale, replacing messages with the same key
+func (b *Bundle) Add(locale string, catalog map[string]interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := strings.ToLower(locale)
+	if _, ok := b.messages[key]; !ok {
+		b.messages[key] = make(map[string]Message)
+		b.locales = append(b.locales, locale)
+		sort.Strings(b.locales)
+	}
+
+	flatten(b.messages[key], "", catalog)
+}
+
+func flatten(messages map[string]Message, prefix string, catalog map[string]interface{}) {
+	for name, value := range catalog {
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		switch v := value.(type) {
+		case string:
+			messages[key] = Message{"other": v}
+		case map[string]interface{}:
+			if isPlural(v) {
+				m := make(Message)
+				for form, text := range v {
+					m[form] = fmt.Sprint(text)
+				}
+				messages[key] = m
+			} else {
+				flatten(messages, key, v)
+			}
+		default:
+			messages[key] = Message{"other": fmt.Sprint(v)}
+		}
+	}
+}
+
+// isPlural reports whether the object is a message with plural forms
+func isPlural(v map[string]interface{}) bool {
+	if _, ok := v["other"]; !ok {
+		return false
+	}
+
+	for form, text := range v {
+		if _, ok := text.(string); !ok || !pluralForms[form] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Locales returns the locales that have a catalog, sorted
+func (b *Bundle) Locales() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return append([]string(nil), b.locales...)
+}
+
+// Supported returns the name of the locale with a catalog that matches locale, ignoring case,
+// and reports whether there is one
+func (b *Bundle) Supported(locale string) (string, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, l := range b.locales {
+		if strings.EqualFold(l, locale) {
+			return l, true
+		}
+	}
+
+	return "", false
+}
+
+// Match returns the best locale with a catalog for a list of preferred locales, e.g. from
+// Accept-Language, trying every preference as given before its base language
+func (b *Bundle) Match(preferences ...string) (string, bool) {
+	for _, p := range preferences {
+		if l, ok := b.Supported(p); ok {
+			return l, true
+		}
+	}
+
+	for _, p := range preferences {
+		if l, ok := b.Supported(base(p)); ok {
+			return l, true
+		}
+	}
+
+	return "", false
+}
+
+// base returns the language of a locale, e.g. pt for pt-BR
+func base(locale string) string {
+	lang, _, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	return lang
+}
+
+// lookup finds the message for key in locale, its base language or the fallback locale
+func (b *Bundle) lookup(locale, key string) (Message, string, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, l := range []string{locale, base(locale), b.Fallback} {
+		if m, ok := b.messages[strings.ToLower(l)][key]; ok {
+			return m, l, true
+		}
+	}
+
+	return nil, "", false
+}
+
+// Has reports whether there is a message for key in locale, its base language or the
+// fallback locale
+func (b *Bundle) Has(locale, key string) bool {
+	_, _, ok := b.lookup(locale, key)
+	return ok
+}
+
+// Translate returns the message for key in locale, with its placeholders replaced by args.
+// Args are either pairs of names and values, or a single map, e.g.
+//
+//	b.Translate("en", "cart.items", "count", 3) // 3 items
+//
+// A count argument picks the plural form of the message
+func (b *Bundle) Translate(locale, key string, args ...interface{}) string {
+	if b == nil {
+		return key
+	}
+
+	m, found, ok := b.lookup(locale, key)
+	if !ok {
+		return key
+	}
+
+	values := arguments(args)
+
+	text := m["other"]
+	if count, isCount := toInt(values["count"]); isCount {
+		form := PluralForm(found, count)
+		if t, ok := m["zero"]; ok && count == 0 {
+			text = t
+		} else if t, ok := m[form]; ok {
+			text = t
+		}
+	}
+
+	return replace(text, values)
+}
+
+// T translates key into the locale of the request that ctx belongs to
+func (b *Bundle) T(ctx context.Context, key string, args ...interface{}) string {
+	return b.Translate(Locale(ctx), key, args...)
+}
+
+// TemplateFunc returns the t function for templates rendered for r, e.g.
+//
+//	{{ t("cart.items", "count", len(items)) }}
+func (b *Bundle) TemplateFunc(r *http.Request) interface{} {
+	locale := Locale(r.Context())
+	return func(key string, args ...interface{}) string {
+		return b.Translate(locale, key, args...)
+	}
+}
+
+// Format replaces the placeholders in text, e.g. {name}, with args, which are given as they
+// are to Translate
+func Format(text string, args ...interface{}) string {
+	return replace(text, arguments(args))
+}
+
+func replace(text string, values map[string]interface{}) string {
+	if len(values) == 0 || !strings.Contains(text, "{") {
+		return text
+	}
+
+	pairs := make([]string, 0, len(values)*2)
+	for name, value := range values {
+		pairs = append(pairs, "{"+name+"}", fmt.Sprint(value))
+	}
+
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// arguments turns pairs of names and values, or a single map, into a map
+func arguments(args []interface{}) map[string]interface{} {
+	if len(args) == 1 {
+		switch m := args[0].(type) {
+		case map[string]interface{}:
+			return m
+		case map[string]string:
+			values := make(map[string]interface{}, len(m))
+			for k, v := range m {
+				values[k] = v
+			}
+			return values
+		}
+	}
+
+	values := make(map[string]interface{}, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		values[fmt.Sprint(args[i])] = args[i+1]
+	}
+
+	return values
+}
+
+func toInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int8:
+		return int(n), true
+	case int16:
+		return int(n), true
+	case int32:
+		return int(n), true
+	case int64:
+		return int(n), true
+	case uint:
+		return int(n), true
+	case uint8:
+		return int(n), true
+	case uint16:
+		return int(n), true
+	case uint32:
+		return int(n), true
+	case uint64:
+		return int(n), true
+	case float32:
+		return int(n), true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
+
+// WithLocale returns a copy of ctx that carries locale
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, contextKey{}, locale)
+}
+
+// Locale returns the locale stored in ctx, e.g. by the Celeritas locale middleware, or an
+// empty string, in which case translations use the fallback locale
+func Locale(ctx context.Context) string {
+	locale, _ := ctx.Value(contextKey{}).(string)
+	return locale
+}
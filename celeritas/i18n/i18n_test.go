@@ -0,0 +1,184 @@
+package i18n
+
+import (
+	"context"
+	"io/fs"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func writeCatalogs(t *testing.T) string {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"en.json":         `{"welcome": "Hello {name}", "cart": {"items": {"zero": "Your cart is empty", "one": "{count} item", "other": "{count} items"}}}`,
+		"fr.toml":         "welcome = \"Bonjour {name}\"\n\n[cart.items]\none = \"{count} article\"\nother = \"{count} articles\"\n",
+		"pt-BR/auth.json": `{"auth": {"failed": "Falha no login"}}`,
+		"README.md":       "not a catalog",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+var translateTests = []struct {
+	name     string
+	locale   string
+	key      string
+	args     []interface{}
+	expected string
+}{
+	{"placeholder", "en", "welcome", []interface{}{"name", "Ann"}, "Hello Ann"},
+	{"map", "fr", "welcome", []interface{}{map[string]string{"name": "Ann"}}, "Bonjour Ann"},
+	{"zero", "en", "cart.items", []interface{}{"count", 0}, "Your cart is empty"},
+	{"one", "en", "cart.items", []interface{}{"count", 1}, "1 item"},
+	{"other", "en", "cart.items", []interface{}{"count", 5}, "5 items"},
+	{"french-zero-is-one", "fr", "cart.items", []interface{}{"count", 0}, "0 article"},
+	{"french-other", "fr", "cart.items", []interface{}{"count", 2}, "2 articles"},
+	{"directory", "pt-BR", "auth.failed", nil, "Falha no login"},
+	{"base-language", "fr-CA", "welcome", []interface{}{"name", "Ann"}, "Bonjour Ann"},
+	{"fallback", "de", "welcome", []interface{}{"name", "Ann"}, "Hello Ann"},
+	{"missing", "en", "nope", nil, "nope"},
+}
+
+func TestBundle_Translate(t *testing.T) {
+	b, err := Load(writeCatalogs(t), "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(b.Locales(), []string{"en", "fr", "pt-BR"}) {
+		t.Errorf("wrong locales %v", b.Locales())
+	}
+
+	for _, e := range translateTests {
+		got := b.Translate(e.locale, e.key, e.args...)
+		if got != e.expected {
+			t.Errorf("%s: expected %q, got %q", e.name, e.expected, got)
+		}
+	}
+}
+
+func TestLoad_MissingDirectory(t *testing.T) {
+	b, err := Load(filepath.Join(t.TempDir(), "lang"), "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(b.Locales()) != 0 {
+		t.Error("expected no locales")
+	}
+}
+
+func TestBundle_Nil(t *testing.T) {
+	var b *Bundle
+	if b.Translate("en", "welcome") != "welcome" {
+		t.Error("expected a nil bundle to return the key")
+	}
+}
+
+var matchTests = []struct {
+	name     string
+	header   string
+	expected string
+	found    bool
+}{
+	{"exact", "pt-BR,en;q=0.5", "pt-BR", true},
+	{"case", "PT-br", "pt-BR", true},
+	{"quality", "de;q=0.9,fr;q=0.8,en;q=0.1", "fr", true},
+	{"base", "fr-CH, de", "fr", true},
+	{"exact-before-base", "fr-CH, en", "en", true},
+	{"refused", "fr;q=0, de", "", false},
+	{"none", "", "", false},
+}
+
+func TestBundle_Match(t *testing.T) {
+	b, err := Load(writeCatalogs(t), "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range matchTests {
+		got, found := b.Match(ParseAcceptLanguage(e.header)...)
+		if got != e.expected || found != e.found {
+			t.Errorf("%s: expected %q %v, got %q %v", e.name, e.expected, e.found, got, found)
+		}
+	}
+}
+
+var pluralTests = []struct {
+	locale   string
+	n        int
+	expected string
+}{
+	{"en", 1, "one"},
+	{"en", 0, "other"},
+	{"fr", 1, "one"},
+	{"fr", 0, "one"},
+	{"ja", 1, "other"},
+	{"ru", 21, "one"},
+	{"ru", 3, "few"},
+	{"ru", 11, "many"},
+	{"pl", 22, "few"},
+	{"pl", 25, "many"},
+	{"cs", 4, "few"},
+	{"ar", 0, "zero"},
+	{"ar", 2, "two"},
+}
+
+func TestPluralForm(t *testing.T) {
+	for _, e := range pluralTests {
+		got := PluralForm(e.locale, e.n)
+		if got != e.expected {
+			t.Errorf("%s %d: expected %s, got %s", e.locale, e.n, e.expected, got)
+		}
+	}
+}
+
+func TestBundle_TemplateFunc(t *testing.T) {
+	b := New("en")
+	b.Add("en", map[string]interface{}{"welcome": "Hello"})
+	b.Add("fr", map[string]interface{}{"welcome": "Bonjour"})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r = r.WithContext(WithLocale(context.Background(), "fr"))
+
+	t9n := b.TemplateFunc(r).(func(string, ...interface{}) string)
+	if t9n("welcome") != "Bonjour" {
+		t.Errorf("expected Bonjour, got %q", t9n("welcome"))
+	}
+}
+
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"lang/en.json":      {Data: []byte(`{"welcome": "Hello"}`)},
+		"lang/fr/site.toml": {Data: []byte(`welcome = "Bonjour"`)},
+	}
+
+	lang, err := fs.Sub(fsys, "lang")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := LoadFS(lang, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.Translate("fr", "welcome") != "Bonjour" || b.Translate("de", "welcome") != "Hello" {
+		t.Error("wrong translations loaded from an fs.FS")
+	}
+}
@@ -0,0 +1,129 @@
+package celeritas
+
+import (
+	"github.com/alexedwards/scs/v2"
+	"github.com/fouched/celeritas/i18n"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var localizeTests = []struct {
+	name     string
+	path     string
+	cookie   string
+	accept   string
+	expected string
+	route    string
+}{
+	{"default", "/about", "", "", "en", "/about"},
+	{"prefix", "/fr/about", "", "", "fr", "/about"},
+	{"prefix-root", "/fr", "", "", "fr", "/"},
+	{"not-a-locale", "/de/about", "", "", "en", "/de/about"},
+	{"cookie", "/about", "fr", "en", "fr", "/about"},
+	{"unsupported-cookie", "/about", "de", "", "en", "/about"},
+	{"accept-language", "/about", "", "de, fr-CA;q=0.8", "fr", "/about"},
+	{"prefix-over-cookie", "/en/about", "fr", "", "en", "/about"},
+}
+
+func newLocalizedCeleritas() *Celeritas {
+	b := i18n.New("en")
+	b.Add("en", map[string]interface{}{"welcome": "Hello"})
+	b.Add("fr", map[string]interface{}{"welcome": "Bonjour"})
+
+	c := &Celeritas{I18n: b, Session: scs.New()}
+	c.config.I18n = I18nConfig{DefaultLocale: "en", Cookie: "lang"}
+	return c
+}
+
+func TestCeleritas_Localize(t *testing.T) {
+	c := newLocalizedCeleritas()
+
+	var locale, route string
+	handler := c.Session.LoadAndSave(c.Localize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale = i18n.Locale(r.Context())
+		route = r.URL.Path
+	})))
+
+	for _, e := range localizeTests {
+		r := httptest.NewRequest("GET", e.path, nil)
+		if e.cookie != "" {
+			r.AddCookie(&http.Cookie{Name: "lang", Value: e.cookie})
+		}
+		if e.accept != "" {
+			r.Header.Set("Accept-Language", e.accept)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if locale != e.expected {
+			t.Errorf("%s: expected locale %s, got %s", e.name, e.expected, locale)
+		}
+		if route != e.route {
+			t.Errorf("%s: expected path %s, got %s", e.name, e.route, route)
+		}
+		if w.Header().Get("Content-Language") != e.expected {
+			t.Errorf("%s: wrong Content-Language %q", e.name, w.Header().Get("Content-Language"))
+		}
+	}
+}
+
+func TestCeleritas_SetLocale(t *testing.T) {
+	c := newLocalizedCeleritas()
+
+	var locale string
+	set := c.Session.LoadAndSave(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.SetLocale(w, r, "fr")
+	}))
+	get := c.Session.LoadAndSave(c.Localize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale = i18n.Locale(r.Context())
+	})))
+
+	w := httptest.NewRecorder()
+	set.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	// the session alone remembers the locale
+	var session *http.Cookie
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == c.Session.Cookie.Name {
+			session = cookie
+		}
+	}
+	if session == nil {
+		t.Fatal("expected a session cookie")
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(session)
+	get.ServeHTTP(httptest.NewRecorder(), r)
+
+	if locale != "fr" {
+		t.Errorf("expected the locale from the session, got %q", locale)
+	}
+}
+
+func TestValidation_Translated(t *testing.T) {
+	b := i18n.New("en")
+	b.Add("fr", map[string]interface{}{"validation": map[string]interface{}{
+		"required":   "{field} est obligatoire",
+		"min_length": "{field} doit contenir au moins {length} caractères",
+	}})
+
+	v := &Validation{Errors: make(map[string]string), Messages: b}
+
+	v.Required(Field{Name: "email", Label: "Email"})
+	v.IsLength(Field{Name: "name", Label: "Name", Value: "ab"}, 3)
+	if v.Errors["email"] != "Email cannot be blank" || v.Errors["name"] != "Name must be at least 3 characters" {
+		t.Errorf("expected the English messages, got %v", v.Errors)
+	}
+
+	v = &Validation{Errors: make(map[string]string), Messages: b}
+	v.WithLocale("fr")
+
+	v.Required(Field{Name: "email", Label: "Email"})
+	v.IsLength(Field{Name: "name", Label: "Nom", Value: "ab"}, 3)
+	if v.Errors["email"] != "Email est obligatoire" || v.Errors["name"] != "Nom doit contenir au moins 3 caractères" {
+		t.Errorf("expected the French messages, got %v", v.Errors)
+	}
+}
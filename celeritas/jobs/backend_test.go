@@ -0,0 +1,182 @@
+package jobs
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var backendTests = []struct {
+	name    string
+	backend Backend
+}{
+	{"redis", &testRedisBackend},
+	{"badger", &testBadgerBackend},
+	{"database", &testDatabaseBackend},
+}
+
+func newTestJob(t *testing.T, queue string, availableAt time.Time) *Job {
+	id, err := newID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Job{
+		ID:          id,
+		Queue:       queue,
+		Type:        "test",
+		Payload:     []byte(`{"n":1}`),
+		MaxAttempts: 3,
+		AvailableAt: availableAt,
+		CreatedAt:   time.Now(),
+	}
+}
+
+func TestBackend_PushPop(t *testing.T) {
+	for _, e := range backendTests {
+		queue := e.name + "-push-pop"
+		now := time.Now()
+
+		later := newTestJob(t, queue, now.Add(time.Hour))
+		first := newTestJob(t, queue, now.Add(-2*time.Second))
+		second := newTestJob(t, queue, now.Add(-time.Second))
+
+		for _, job := range []*Job{later, second, first} {
+			if err := e.backend.Push(job); err != nil {
+				t.Fatal(e.name, err)
+			}
+		}
+
+		if size, _ := e.backend.Size(queue); size != 3 {
+			t.Errorf("%s: expected 3 jobs on the queue, got %d", e.name, size)
+		}
+
+		job, err := e.backend.Pop(queue, time.Minute)
+		if err != nil {
+			t.Fatal(e.name, err)
+		}
+
+		if job == nil || job.ID != first.ID {
+			t.Fatalf("%s: expected the oldest available job first", e.name)
+		}
+
+		if job.Attempts != 1 {
+			t.Errorf("%s: expected attempts to be 1, got %d", e.name, job.Attempts)
+		}
+
+		if string(job.Payload) != `{"n":1}` {
+			t.Errorf("%s: wrong payload %s", e.name, job.Payload)
+		}
+
+		// the first job is reserved, so the second is next
+		job, _ = e.backend.Pop(queue, time.Minute)
+		if job == nil || job.ID != second.ID {
+			t.Fatalf("%s: expected the second job", e.name)
+		}
+
+		// the delayed job is not available yet
+		job, _ = e.backend.Pop(queue, time.Minute)
+		if job != nil {
+			t.Errorf("%s: popped a job that is not available yet", e.name)
+		}
+
+		_ = e.backend.Delete(first)
+		_ = e.backend.Delete(second)
+		_ = e.backend.Delete(later)
+
+		if size, _ := e.backend.Size(queue); size != 0 {
+			t.Errorf("%s: expected an empty queue, got %d jobs", e.name, size)
+		}
+	}
+}
+
+func TestBackend_ReservationExpires(t *testing.T) {
+	for _, e := range backendTests {
+		queue := e.name + "-reservation"
+		_ = e.backend.Push(newTestJob(t, queue, time.Now()))
+
+		job, _ := e.backend.Pop(queue, -time.Second)
+		if job == nil {
+			t.Fatal(e.name, "expected a job")
+		}
+
+		// the worker died without deleting or releasing the job, so it comes back
+		again, _ := e.backend.Pop(queue, time.Minute)
+		if again == nil || again.ID != job.ID {
+			t.Fatalf("%s: expected the job to be available after its reservation expired", e.name)
+		}
+
+		if again.Attempts != 2 {
+			t.Errorf("%s: expected attempts to be 2, got %d", e.name, again.Attempts)
+		}
+
+		_ = e.backend.Delete(again)
+	}
+}
+
+func TestBackend_FailRetry(t *testing.T) {
+	for _, e := range backendTests {
+		queue := e.name + "-fail"
+		_ = e.backend.Push(newTestJob(t, queue, time.Now()))
+
+		job, _ := e.backend.Pop(queue, time.Minute)
+		job.Error = "boom"
+		job.FailedAt = time.Now()
+
+		err := e.backend.Fail(job)
+		if err != nil {
+			t.Fatal(e.name, err)
+		}
+
+		if size, _ := e.backend.Size(queue); size != 0 {
+			t.Errorf("%s: failed job still on the queue", e.name)
+		}
+
+		failed, err := e.backend.Failed()
+		if err != nil {
+			t.Fatal(e.name, err)
+		}
+
+		found := false
+		for _, f := range failed {
+			if f.ID == job.ID {
+				found = true
+				if f.Error != "boom" || f.Attempts != 1 || f.FailedAt.IsZero() {
+					t.Errorf("%s: failed job not stored correctly: %+v", e.name, f)
+				}
+			}
+		}
+
+		if !found {
+			t.Fatalf("%s: failed job not listed", e.name)
+		}
+
+		err = e.backend.Retry(job.ID)
+		if err != nil {
+			t.Fatal(e.name, err)
+		}
+
+		retried, _ := e.backend.Pop(queue, time.Minute)
+		if retried == nil || retried.ID != job.ID || retried.Attempts != 1 || retried.Error != "" {
+			t.Fatalf("%s: expected the retried job back on its queue with attempts reset, got %+v", e.name, retried)
+		}
+		_ = e.backend.Delete(retried)
+
+		err = e.backend.Retry(job.ID)
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: expected ErrNotFound retrying a job twice, got %v", e.name, err)
+		}
+	}
+}
+
+func TestDatabaseBackend_Rebind(t *testing.T) {
+	b := DatabaseBackend{Type: "postgres"}
+	if q := b.rebind("select * from jobs where id = ? and queue = ?"); q != "select * from jobs where id = $1 and queue = $2" {
+		t.Error("wrong postgres query:", q)
+	}
+
+	b.Type = "mysql"
+	if q := b.rebind("select * from jobs where id = ?"); q != "select * from jobs where id = ?" {
+		t.Error("mysql query should not change:", q)
+	}
+}
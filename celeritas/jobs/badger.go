@@ -0,0 +1,240 @@
+package jobs
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"github.com/dgraph-io/badger/v4"
+	"sort"
+	"time"
+)
+
+// BadgerBackend stores jobs in badger. Badger cannot be shared between processes, so jobs
+// must be processed by the process that dispatched them, e.g. by running workers in the web
+// server, rather than with a separate worker process. Use a database of its own for Conn,
+// since emptying a badger cache deletes every key in its database
+type BadgerBackend struct {
+	Conn *badger.DB
+}
+
+func queuePrefix(queue string) []byte {
+	return []byte("jobs:queue:" + queue + ":")
+}
+
+// jobKey sorts the jobs on a queue by the time they become available, so the first key is
+// the next job due
+func jobKey(job *Job) []byte {
+	key := binary.BigEndian.AppendUint64(queuePrefix(job.Queue), uint64(job.AvailableAt.UnixMilli()))
+	return append(key, job.ID...)
+}
+
+// indexKey points to the current key of the job with id on its queue
+func indexKey(id string) []byte {
+	return []byte("jobs:index:" + id)
+}
+
+func failedKey(id string) []byte {
+	return []byte("jobs:failed:" + id)
+}
+
+func setJob(txn *badger.Txn, key []byte, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return txn.Set(key, data)
+}
+
+// putJob stores job on its queue, replacing the key it was stored under before
+func putJob(txn *badger.Txn, job *Job) error {
+	err := removeJob(txn, job.ID)
+	if err != nil {
+		return err
+	}
+
+	key := jobKey(job)
+	err = setJob(txn, key, job)
+	if err != nil {
+		return err
+	}
+
+	return txn.Set(indexKey(job.ID), key)
+}
+
+// removeJob removes the job with id from its queue
+func removeJob(txn *badger.Txn, id string) error {
+	item, err := txn.Get(indexKey(id))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	key, err := item.ValueCopy(nil)
+	if err != nil {
+		return err
+	}
+
+	err = txn.Delete(key)
+	if err != nil {
+		return err
+	}
+
+	return txn.Delete(indexKey(id))
+}
+
+func getJob(item *badger.Item) (*Job, error) {
+	var job Job
+	err := item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &job)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (b *BadgerBackend) Push(job *Job) error {
+	return b.Conn.Update(func(txn *badger.Txn) error {
+		return putJob(txn, job)
+	})
+}
+
+func (b *BadgerBackend) Pop(queue string, timeout time.Duration) (*Job, error) {
+	var next *Job
+	now := time.Now()
+
+	err := b.Conn.Update(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		prefix := queuePrefix(queue)
+		it.Seek(prefix)
+		if !it.ValidForPrefix(prefix) {
+			return nil
+		}
+
+		job, err := getJob(it.Item())
+		if err != nil {
+			return err
+		}
+
+		if job.AvailableAt.After(now) {
+			return nil
+		}
+
+		job.Attempts++
+		job.AvailableAt = now.Add(timeout)
+		err = putJob(txn, job)
+		if err != nil {
+			return err
+		}
+
+		next = job
+		return nil
+	})
+	if errors.Is(err, badger.ErrConflict) {
+		// another worker reserved a job at the same time, so try again on the next poll
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return next, nil
+}
+
+func (b *BadgerBackend) Delete(job *Job) error {
+	return b.Conn.Update(func(txn *badger.Txn) error {
+		return removeJob(txn, job.ID)
+	})
+}
+
+func (b *BadgerBackend) Release(job *Job, at time.Time) error {
+	job.AvailableAt = at
+	return b.Push(job)
+}
+
+func (b *BadgerBackend) Fail(job *Job) error {
+	return b.Conn.Update(func(txn *badger.Txn) error {
+		err := removeJob(txn, job.ID)
+		if err != nil {
+			return err
+		}
+		return setJob(txn, failedKey(job.ID), job)
+	})
+}
+
+func (b *BadgerBackend) Failed() ([]*Job, error) {
+	var failed []*Job
+
+	err := b.Conn.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := []byte("jobs:failed:")
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			job, err := getJob(it.Item())
+			if err != nil {
+				return err
+			}
+			failed = append(failed, job)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(failed, func(i, j int) bool {
+		return failed[i].FailedAt.Before(failed[j].FailedAt)
+	})
+
+	return failed, nil
+}
+
+func (b *BadgerBackend) Retry(id string) error {
+	return b.Conn.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(failedKey(id))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return ErrNotFound
+		} else if err != nil {
+			return err
+		}
+
+		job, err := getJob(item)
+		if err != nil {
+			return err
+		}
+
+		resetForRetry(job)
+		err = putJob(txn, job)
+		if err != nil {
+			return err
+		}
+
+		return txn.Delete(failedKey(id))
+	})
+}
+
+func (b *BadgerBackend) Size(queue string) (int, error) {
+	size := 0
+
+	err := b.Conn.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		prefix := queuePrefix(queue)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			size++
+		}
+
+		return nil
+	})
+
+	return size, err
+}
@@ -0,0 +1,203 @@
+package jobs
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DatabaseBackend stores jobs in the jobs and failed_jobs tables, which are created with
+// `celeritas make jobs`. Times are stored as unix milliseconds, so the tables look the same
+// on every database
+type DatabaseBackend struct {
+	DB   *sql.DB
+	Type string // the database type, e.g. postgres or mysql
+}
+
+const jobColumns = "id, queue, type, payload, attempts, max_attempts, available_at, created_at, error"
+
+// popAttempts is how often Pop tries to reserve a job that other workers are reserving at the same time
+const popAttempts = 3
+
+// rebind replaces ? placeholders with $1, $2, ... for postgres
+func (b *DatabaseBackend) rebind(query string) string {
+	if b.Type != "postgres" && b.Type != "postgresql" {
+		return query
+	}
+
+	var sb strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			sb.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+func (b *DatabaseBackend) Push(job *Job) error {
+	_, err := b.DB.Exec(b.rebind("insert into jobs ("+jobColumns+") values (?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+		job.ID, job.Queue, job.Type, string(job.Payload), job.Attempts, job.MaxAttempts,
+		job.AvailableAt.UnixMilli(), job.CreatedAt.UnixMilli(), job.Error)
+
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanJob(row scanner, extra ...any) (*Job, error) {
+	var job Job
+	var payload string
+	var availableAt, createdAt int64
+
+	dest := append([]any{&job.ID, &job.Queue, &job.Type, &payload, &job.Attempts, &job.MaxAttempts,
+		&availableAt, &createdAt, &job.Error}, extra...)
+
+	err := row.Scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Payload = []byte(payload)
+	job.AvailableAt = time.UnixMilli(availableAt)
+	job.CreatedAt = time.UnixMilli(createdAt)
+
+	return &job, nil
+}
+
+func (b *DatabaseBackend) Pop(queue string, timeout time.Duration) (*Job, error) {
+	for i := 0; i < popAttempts; i++ {
+		now := time.Now()
+
+		row := b.DB.QueryRow(b.rebind("select "+jobColumns+" from jobs where queue = ? and available_at <= ? order by available_at limit 1"),
+			queue, now.UnixMilli())
+
+		job, err := scanJob(row)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		// reserve the job, unless another worker got to it first
+		result, err := b.DB.Exec(b.rebind("update jobs set available_at = ?, attempts = attempts + 1 where id = ? and available_at = ?"),
+			now.Add(timeout).UnixMilli(), job.ID, job.AvailableAt.UnixMilli())
+		if err != nil {
+			return nil, err
+		}
+
+		reserved, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+
+		if reserved == 1 {
+			job.Attempts++
+			job.AvailableAt = now.Add(timeout)
+			return job, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (b *DatabaseBackend) Delete(job *Job) error {
+	_, err := b.DB.Exec(b.rebind("delete from jobs where id = ?"), job.ID)
+	return err
+}
+
+func (b *DatabaseBackend) Release(job *Job, at time.Time) error {
+	job.AvailableAt = at
+	_, err := b.DB.Exec(b.rebind("update jobs set available_at = ?, error = ? where id = ?"),
+		at.UnixMilli(), job.Error, job.ID)
+
+	return err
+}
+
+func (b *DatabaseBackend) Fail(job *Job) error {
+	tx, err := b.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(b.rebind("insert into failed_jobs ("+jobColumns+", failed_at) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+		job.ID, job.Queue, job.Type, string(job.Payload), job.Attempts, job.MaxAttempts,
+		job.AvailableAt.UnixMilli(), job.CreatedAt.UnixMilli(), job.Error, job.FailedAt.UnixMilli())
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(b.rebind("delete from jobs where id = ?"), job.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (b *DatabaseBackend) Failed() ([]*Job, error) {
+	rows, err := b.DB.Query("select " + jobColumns + ", failed_at from failed_jobs order by failed_at")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var failed []*Job
+	for rows.Next() {
+		var failedAt int64
+		job, err := scanJob(rows, &failedAt)
+		if err != nil {
+			return nil, err
+		}
+		job.FailedAt = time.UnixMilli(failedAt)
+		failed = append(failed, job)
+	}
+
+	return failed, rows.Err()
+}
+
+func (b *DatabaseBackend) Retry(id string) error {
+	tx, err := b.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRow(b.rebind("select "+jobColumns+" from failed_jobs where id = ?"), id)
+	job, err := scanJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+
+	resetForRetry(job)
+	_, err = tx.Exec(b.rebind("insert into jobs ("+jobColumns+") values (?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+		job.ID, job.Queue, job.Type, string(job.Payload), job.Attempts, job.MaxAttempts,
+		job.AvailableAt.UnixMilli(), job.CreatedAt.UnixMilli(), job.Error)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(b.rebind("delete from failed_jobs where id = ?"), id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (b *DatabaseBackend) Size(queue string) (int, error) {
+	var size int
+	err := b.DB.QueryRow(b.rebind("select count(*) from jobs where queue = ?"), queue).Scan(&size)
+	return size, err
+}
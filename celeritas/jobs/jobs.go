@@ -0,0 +1,351 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Job is a unit of work stored in a queue until a worker processes it
+type Job struct {
+	ID          string          `json:"id"`
+	Queue       string          `json:"queue"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	AvailableAt time.Time       `json:"available_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	Error       string          `json:"error,omitempty"` // the error of the last attempt
+	FailedAt    time.Time       `json:"failed_at"`       // only set for failed jobs
+}
+
+// Backend stores jobs. Pop reserves the next available job on a queue for timeout and
+// increments its attempts, or returns nil if no job is available. A reserved job that is
+// neither deleted, released nor failed before the reservation ends, e.g. because the worker
+// crashed, becomes available again
+type Backend interface {
+	Push(job *Job) error
+	Pop(queue string, timeout time.Duration) (*Job, error)
+	Delete(job *Job) error
+	Release(job *Job, at time.Time) error
+	Fail(job *Job) error
+	Failed() ([]*Job, error)
+	Retry(id string) error
+	Size(queue string) (int, error)
+}
+
+// DefaultQueue is the queue jobs are dispatched to, unless OnQueue says otherwise
+const DefaultQueue = "default"
+
+// reservationGrace is how long a job stays reserved after its handler timed out, so that
+// a handler that is slow to return when its context is cancelled does not run twice
+const reservationGrace = 30 * time.Second
+
+// ErrNotFound is returned when retrying a failed job that does not exist
+var ErrNotFound = errors.New("job not found")
+
+// permanentError is an error that should not be retried
+type permanentError struct {
+	err error
+}
+
+func (p permanentError) Error() string { return p.err.Error() }
+func (p permanentError) Unwrap() error { return p.err }
+
+// Permanent wraps err so that the job fails right away, instead of being retried
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Queue dispatches jobs to a backend, and runs workers that process them
+type Queue struct {
+	Backend      Backend
+	Logger       *slog.Logger
+	Workers      int                             // number of jobs processed at the same time
+	MaxAttempts  int                             // default number of attempts before a job fails
+	Timeout      time.Duration                   // how long a job may run, it stays reserved for a grace period longer
+	PollInterval time.Duration                   // how long idle workers wait before checking for jobs again
+	Backoff      func(attempt int) time.Duration // how long to wait before retrying after attempt
+
+	mu       sync.RWMutex
+	handlers map[string]func(ctx context.Context, payload json.RawMessage) error
+}
+
+// Option changes how a job is dispatched
+type Option func(job *Job)
+
+// OnQueue dispatches the job to the named queue
+func OnQueue(name string) Option {
+	return func(job *Job) {
+		job.Queue = name
+	}
+}
+
+// Delay makes the job available after d
+func Delay(d time.Duration) Option {
+	return func(job *Job) {
+		job.AvailableAt = time.Now().Add(d)
+	}
+}
+
+// At makes the job available at t
+func At(t time.Time) Option {
+	return func(job *Job) {
+		job.AvailableAt = t
+	}
+}
+
+// MaxAttempts sets the number of attempts before the job fails
+func MaxAttempts(n int) Option {
+	return func(job *Job) {
+		job.MaxAttempts = n
+	}
+}
+
+// Handle registers the handler for jobs of type jobType. The payload of the job is decoded
+// into a T before it is passed to the handler, e.g.
+//
+//	jobs.Handle(q, "send-invoice", func(ctx context.Context, invoice Invoice) error { ... })
+func Handle[T any](q *Queue, jobType string, handler func(ctx context.Context, payload T) error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.handlers == nil {
+		q.handlers = make(map[string]func(ctx context.Context, payload json.RawMessage) error)
+	}
+
+	q.handlers[jobType] = func(ctx context.Context, raw json.RawMessage) error {
+		var payload T
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return Permanent(fmt.Errorf("decode payload: %w", err))
+		}
+		return handler(ctx, payload)
+	}
+}
+
+// Dispatch stores a job of type jobType, with payload encoded as JSON, and returns its id
+func (q *Queue) Dispatch(jobType string, payload any, opts ...Option) (string, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	id, err := newID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	job := &Job{
+		ID:          id,
+		Queue:       DefaultQueue,
+		Type:        jobType,
+		Payload:     raw,
+		MaxAttempts: q.MaxAttempts,
+		AvailableAt: now,
+		CreatedAt:   now,
+	}
+
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	if job.MaxAttempts < 1 {
+		job.MaxAttempts = 1
+	}
+
+	err = q.Backend.Push(job)
+	if err != nil {
+		return "", err
+	}
+
+	return job.ID, nil
+}
+
+// Work processes jobs from queues, in order of priority, until ctx is cancelled, and then
+// waits for the jobs in progress to finish
+func (q *Queue) Work(ctx context.Context, queues ...string) error {
+	if len(queues) == 0 {
+		queues = []string{DefaultQueue}
+	}
+
+	workers := max(q.Workers, 1)
+	q.logger().Info("processing jobs", "queues", queues, "workers", workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.worker(ctx, queues)
+		}()
+	}
+
+	wg.Wait()
+	return nil
+}
+
+// worker processes one job at a time until ctx is cancelled
+func (q *Queue) worker(ctx context.Context, queues []string) {
+	for ctx.Err() == nil {
+		if q.next(queues) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(q.pollInterval()):
+		}
+	}
+}
+
+// next processes the first available job, and reports whether there was one
+func (q *Queue) next(queues []string) bool {
+	for _, queue := range queues {
+		job, err := q.Backend.Pop(queue, q.timeout()+reservationGrace)
+		if err != nil {
+			q.logger().Error("could not fetch job", "queue", queue, "error", err)
+			continue
+		}
+
+		if job != nil {
+			q.process(job)
+			return true
+		}
+	}
+
+	return false
+}
+
+// process runs the handler for job, and deletes, retries or fails the job depending on the outcome
+func (q *Queue) process(job *Job) {
+	log := q.logger().With("job", job.ID, "type", job.Type, "queue", job.Queue, "attempt", job.Attempts)
+	start := time.Now()
+
+	err := q.run(job)
+	if err == nil {
+		if err := q.Backend.Delete(job); err != nil {
+			log.Error("could not delete processed job", "error", err)
+		}
+		log.Info("job processed", "duration", time.Since(start))
+		return
+	}
+
+	job.Error = err.Error()
+
+	var permanent permanentError
+	if errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts {
+		job.FailedAt = time.Now()
+		if err := q.Backend.Fail(job); err != nil {
+			log.Error("could not store failed job", "error", err)
+		}
+		log.Error("job failed", "duration", time.Since(start), "error", err)
+		return
+	}
+
+	wait := q.backoff(job.Attempts)
+	if err := q.Backend.Release(job, time.Now().Add(wait)); err != nil {
+		log.Error("could not release job for retry", "error", err)
+	}
+	log.Warn("job will be retried", "duration", time.Since(start), "retry_in", wait, "error", err)
+}
+
+// run calls the handler for job with a timeout, turning a panic into an error
+func (q *Queue) run(job *Job) (err error) {
+	q.mu.RLock()
+	handler, ok := q.handlers[job.Type]
+	q.mu.RUnlock()
+
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for job type %q", job.Type))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), q.timeout())
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return handler(ctx, job.Payload)
+}
+
+// Failed returns the jobs that failed, oldest first
+func (q *Queue) Failed() ([]*Job, error) {
+	return q.Backend.Failed()
+}
+
+// Retry puts the failed job with id back on its queue, with its attempts reset
+func (q *Queue) Retry(id string) error {
+	return q.Backend.Retry(id)
+}
+
+// Size returns the number of jobs on queue, including delayed and reserved jobs
+func (q *Queue) Size(queue string) (int, error) {
+	return q.Backend.Size(queue)
+}
+
+func (q *Queue) timeout() time.Duration {
+	if q.Timeout <= 0 {
+		return 5 * time.Minute
+	}
+	return q.Timeout
+}
+
+func (q *Queue) pollInterval() time.Duration {
+	if q.PollInterval <= 0 {
+		return time.Second
+	}
+	return q.PollInterval
+}
+
+func (q *Queue) backoff(attempt int) time.Duration {
+	if q.Backoff != nil {
+		return q.Backoff(attempt)
+	}
+	return DefaultBackoff(attempt)
+}
+
+func (q *Queue) logger() *slog.Logger {
+	if q.Logger == nil {
+		return slog.Default()
+	}
+	return q.Logger
+}
+
+// DefaultBackoff waits 10 seconds after the first attempt, and doubles the wait after every
+// attempt after that, up to an hour
+func DefaultBackoff(attempt int) time.Duration {
+	wait := 10 * time.Second
+	for i := 1; i < attempt && wait < time.Hour; i++ {
+		wait *= 2
+	}
+	return min(wait, time.Hour)
+}
+
+// newID returns a random job id
+func newID() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// resetForRetry prepares a failed job to go back on its queue
+func resetForRetry(job *Job) {
+	job.Attempts = 0
+	job.Error = ""
+	job.FailedAt = time.Time{}
+	job.AvailableAt = time.Now()
+}
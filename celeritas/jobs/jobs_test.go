@@ -0,0 +1,225 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type invoice struct {
+	Number int    `json:"number"`
+	Email  string `json:"email"`
+}
+
+func newTestQueue(backend Backend) *Queue {
+	return &Queue{
+		Backend:      backend,
+		Logger:       testLogger,
+		Workers:      2,
+		MaxAttempts:  3,
+		PollInterval: 5 * time.Millisecond,
+		Backoff:      func(attempt int) time.Duration { return 0 },
+	}
+}
+
+// work runs the workers until all jobs on queue are processed
+func work(t *testing.T, q *Queue, queue string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		_ = q.Work(ctx, queue)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		size, _ := q.Size(queue)
+		if size == 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	<-done
+}
+
+func TestQueue_Dispatch(t *testing.T) {
+	for _, e := range backendTests {
+		queue := e.name + "-dispatch"
+		q := newTestQueue(e.backend)
+
+		var mu sync.Mutex
+		var handled []invoice
+
+		Handle(q, "send-invoice", func(ctx context.Context, inv invoice) error {
+			mu.Lock()
+			defer mu.Unlock()
+			handled = append(handled, inv)
+			return nil
+		})
+
+		_, err := q.Dispatch("send-invoice", invoice{Number: 42, Email: "me@here.com"}, OnQueue(queue))
+		if err != nil {
+			t.Fatal(e.name, err)
+		}
+
+		work(t, q, queue)
+
+		if len(handled) != 1 || handled[0].Number != 42 || handled[0].Email != "me@here.com" {
+			t.Errorf("%s: expected the invoice to be handled once, got %v", e.name, handled)
+		}
+	}
+}
+
+func TestQueue_Retry(t *testing.T) {
+	for _, e := range backendTests {
+		queue := e.name + "-retry"
+		q := newTestQueue(e.backend)
+
+		attempts := 0
+		Handle(q, "flaky", func(ctx context.Context, payload string) error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("try again")
+			}
+			return nil
+		})
+
+		_, _ = q.Dispatch("flaky", "payload", OnQueue(queue))
+		work(t, q, queue)
+
+		if attempts != 3 {
+			t.Errorf("%s: expected 3 attempts, got %d", e.name, attempts)
+		}
+	}
+}
+
+func TestQueue_Fail(t *testing.T) {
+	for _, e := range backendTests {
+		queue := e.name + "-failing"
+		q := newTestQueue(e.backend)
+
+		Handle(q, "always-fails", func(ctx context.Context, payload string) error {
+			return errors.New("boom")
+		})
+
+		Handle(q, "panics", func(ctx context.Context, payload string) error {
+			panic("oops")
+		})
+
+		Handle(q, "permanent", func(ctx context.Context, payload string) error {
+			return Permanent(errors.New("invalid"))
+		})
+
+		failing, _ := q.Dispatch("always-fails", "x", OnQueue(queue), MaxAttempts(2))
+		panics, _ := q.Dispatch("panics", "x", OnQueue(queue), MaxAttempts(1))
+		permanent, _ := q.Dispatch("permanent", "x", OnQueue(queue))
+		unknown, _ := q.Dispatch("unknown", "x", OnQueue(queue))
+
+		work(t, q, queue)
+
+		failed, err := q.Failed()
+		if err != nil {
+			t.Fatal(e.name, err)
+		}
+
+		byID := make(map[string]*Job)
+		for _, job := range failed {
+			byID[job.ID] = job
+		}
+
+		var tests = []struct {
+			id       string
+			attempts int
+			error    string
+		}{
+			{failing, 2, "boom"},
+			{panics, 1, "panic: oops"},
+			{permanent, 1, "invalid"},
+			{unknown, 1, `no handler for job type "unknown"`},
+		}
+
+		for _, f := range tests {
+			job, ok := byID[f.id]
+			if !ok {
+				t.Errorf("%s: job with error %q did not fail", e.name, f.error)
+				continue
+			}
+
+			if job.Attempts != f.attempts || job.Error != f.error {
+				t.Errorf("%s: expected %d attempt(s) and error %q, got %d and %q", e.name, f.attempts, f.error, job.Attempts, job.Error)
+			}
+		}
+	}
+}
+
+func TestQueue_SlowHandler(t *testing.T) {
+	for _, e := range backendTests {
+		queue := e.name + "-slow"
+		q := newTestQueue(e.backend)
+		q.Timeout = 50 * time.Millisecond
+
+		var mu sync.Mutex
+		runs := 0
+
+		// the handler keeps running a little after its timeout, which must not let the
+		// other worker pick up the same job
+		Handle(q, "slow", func(ctx context.Context, payload string) error {
+			mu.Lock()
+			runs++
+			mu.Unlock()
+
+			<-ctx.Done()
+			time.Sleep(100 * time.Millisecond)
+			return nil
+		})
+
+		_, _ = q.Dispatch("slow", "x", OnQueue(queue))
+		work(t, q, queue)
+
+		if runs != 1 {
+			t.Errorf("%s: expected the job to run once, got %d", e.name, runs)
+		}
+	}
+}
+
+func TestQueue_Delay(t *testing.T) {
+	q := newTestQueue(&testRedisBackend)
+	queue := "delayed"
+
+	_, _ = q.Dispatch("later", "x", OnQueue(queue), Delay(time.Hour))
+
+	job, _ := q.Backend.Pop(queue, time.Minute)
+	if job != nil {
+		t.Error("delayed job available before its time")
+	}
+
+	_, _ = q.Dispatch("at", "x", OnQueue(queue), At(time.Now().Add(-time.Second)))
+
+	job, _ = q.Backend.Pop(queue, time.Minute)
+	if job == nil || job.Type != "at" {
+		t.Error("expected the job dispatched in the past to be available")
+	}
+}
+
+func TestDefaultBackoff(t *testing.T) {
+	var tests = []struct {
+		attempt int
+		wait    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{20, time.Hour},
+	}
+
+	for _, e := range tests {
+		if wait := DefaultBackoff(e.attempt); wait != e.wait {
+			t.Errorf("attempt %d: expected %s but got %s", e.attempt, e.wait, wait)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gomodule/redigo/redis"
+	"sort"
+	"time"
+)
+
+// RedisBackend stores jobs in redis. Every queue is a sorted set of job ids, scored by the
+// time the job becomes available, and the jobs themselves are stored in a hash. The keys start
+// with jobs: rather than Prefix, so that emptying a cache with the same prefix leaves them alone
+type RedisBackend struct {
+	Conn   *redis.Pool
+	Prefix string
+}
+
+// popScript reserves the first available job on a queue by moving its score to the end
+// of the reservation, and counts the attempt in the same step, so that a worker crashing
+// after the reservation cannot skip it. It returns the id, data and attempts of the job,
+// and drops ids whose data is gone
+var popScript = redis.NewScript(3, `
+local ids = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, 1)
+if #ids == 0 then
+	return false
+end
+local data = redis.call("HGET", KEYS[2], ids[1])
+if not data then
+	redis.call("ZREM", KEYS[1], ids[1])
+	return false
+end
+redis.call("ZADD", KEYS[1], ARGV[2], ids[1])
+local attempts = redis.call("HINCRBY", KEYS[3], ids[1], 1)
+return {ids[1], data, attempts}`)
+
+func (b *RedisBackend) queueKey(queue string) string {
+	return fmt.Sprintf("jobs:%s:queue:%s", b.Prefix, queue)
+}
+
+func (b *RedisBackend) dataKey() string {
+	return fmt.Sprintf("jobs:%s:data", b.Prefix)
+}
+
+// attemptsKey is the hash of the attempts of every job, which Pop increments atomically
+func (b *RedisBackend) attemptsKey() string {
+	return fmt.Sprintf("jobs:%s:attempts", b.Prefix)
+}
+
+func (b *RedisBackend) failedKey() string {
+	return fmt.Sprintf("jobs:%s:failed", b.Prefix)
+}
+
+func (b *RedisBackend) Push(job *Job) error {
+	conn := b.Conn.Get()
+	defer conn.Close()
+
+	return b.store(conn, job, job.AvailableAt)
+}
+
+// store saves job, and makes it available on its queue at at
+func (b *RedisBackend) store(conn redis.Conn, job *Job, at time.Time) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	_ = conn.Send("MULTI")
+	_ = conn.Send("HSET", b.dataKey(), job.ID, data)
+	_ = conn.Send("HSET", b.attemptsKey(), job.ID, job.Attempts)
+	_ = conn.Send("ZADD", b.queueKey(job.Queue), at.UnixMilli(), job.ID)
+	_, err = conn.Do("EXEC")
+
+	return err
+}
+
+func (b *RedisBackend) Pop(queue string, timeout time.Duration) (*Job, error) {
+	conn := b.Conn.Get()
+	defer conn.Close()
+
+	now := time.Now()
+	reply, err := redis.Values(popScript.Do(conn, b.queueKey(queue), b.dataKey(), b.attemptsKey(), now.UnixMilli(), now.Add(timeout).UnixMilli()))
+	if errors.Is(err, redis.ErrNil) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var job Job
+	data, _ := redis.Bytes(reply[1], nil)
+	err = json.Unmarshal(data, &job)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Attempts, err = redis.Int(reply[2], nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (b *RedisBackend) Delete(job *Job) error {
+	conn := b.Conn.Get()
+	defer conn.Close()
+
+	_ = conn.Send("MULTI")
+	_ = conn.Send("ZREM", b.queueKey(job.Queue), job.ID)
+	_ = conn.Send("HDEL", b.dataKey(), job.ID)
+	_ = conn.Send("HDEL", b.attemptsKey(), job.ID)
+	_, err := conn.Do("EXEC")
+
+	return err
+}
+
+func (b *RedisBackend) Release(job *Job, at time.Time) error {
+	conn := b.Conn.Get()
+	defer conn.Close()
+
+	job.AvailableAt = at
+	return b.store(conn, job, at)
+}
+
+func (b *RedisBackend) Fail(job *Job) error {
+	conn := b.Conn.Get()
+	defer conn.Close()
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	_ = conn.Send("MULTI")
+	_ = conn.Send("ZREM", b.queueKey(job.Queue), job.ID)
+	_ = conn.Send("HDEL", b.dataKey(), job.ID)
+	_ = conn.Send("HDEL", b.attemptsKey(), job.ID)
+	_ = conn.Send("HSET", b.failedKey(), job.ID, data)
+	_, err = conn.Do("EXEC")
+
+	return err
+}
+
+func (b *RedisBackend) Failed() ([]*Job, error) {
+	conn := b.Conn.Get()
+	defer conn.Close()
+
+	values, err := redis.ByteSlices(conn.Do("HVALS", b.failedKey()))
+	if err != nil {
+		return nil, err
+	}
+
+	var failed []*Job
+	for _, data := range values {
+		var job Job
+		if err := json.Unmarshal(data, &job); err != nil {
+			return nil, err
+		}
+		failed = append(failed, &job)
+	}
+
+	sort.Slice(failed, func(i, j int) bool {
+		return failed[i].FailedAt.Before(failed[j].FailedAt)
+	})
+
+	return failed, nil
+}
+
+func (b *RedisBackend) Retry(id string) error {
+	conn := b.Conn.Get()
+	defer conn.Close()
+
+	data, err := redis.Bytes(conn.Do("HGET", b.failedKey(), id))
+	if errors.Is(err, redis.ErrNil) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+
+	var job Job
+	err = json.Unmarshal(data, &job)
+	if err != nil {
+		return err
+	}
+
+	resetForRetry(&job)
+	err = b.store(conn, &job, job.AvailableAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Do("HDEL", b.failedKey(), id)
+	return err
+}
+
+func (b *RedisBackend) Size(queue string) (int, error) {
+	conn := b.Conn.Get()
+	defer conn.Close()
+
+	return redis.Int(conn.Do("ZCARD", b.queueKey(queue)))
+}
@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"database/sql"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gomodule/redigo/redis"
+	"io"
+	"log"
+	"log/slog"
+	_ "modernc.org/sqlite"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+var testRedisBackend RedisBackend
+var testBadgerBackend BadgerBackend
+var testDatabaseBackend DatabaseBackend
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	s, err := miniredis.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer s.Close()
+
+	testRedisBackend = RedisBackend{
+		Conn: &redis.Pool{
+			Dial: func() (redis.Conn, error) {
+				return redis.Dial("tcp", s.Addr())
+			},
+		},
+		Prefix: "test-celeritas",
+	}
+
+	dir, err := os.MkdirTemp("", "celeritas-jobs")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	bdb, err := badger.Open(badger.DefaultOptions(filepath.Join(dir, "badger")).WithLogger(nil))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer bdb.Close()
+	testBadgerBackend = BadgerBackend{Conn: bdb}
+
+	// create the tables from the same template the cli uses
+	schema, err := os.ReadFile("../cmd/cli/templates/migrations/jobs_tables.sqlite.sql")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(dir, "jobs.db")+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(string(schema))
+	if err != nil {
+		log.Fatal(err)
+	}
+	testDatabaseBackend = DatabaseBackend{DB: db, Type: "sqlite"}
+
+	return m.Run()
+}
@@ -0,0 +1,74 @@
+package celeritas
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/fouched/celeritas/jobs"
+	"testing"
+	"time"
+)
+
+// jobsCacheTests create the cache and job queue the way New does for each backend, and
+// return a function that closes what they opened
+var jobsCacheTests = []struct {
+	name  string
+	setup func(t *testing.T, c *Celeritas) func()
+}{
+	{"redis", func(t *testing.T, c *Celeritas) func() {
+		s := miniredis.RunT(t)
+		c.config.Redis = RedisConfig{Host: s.Addr(), Prefix: "app"}
+
+		redisCache = c.createRedisCache()
+		redisPool = redisCache.Conn
+		c.Cache = redisCache
+
+		return func() {
+			_ = redisPool.Close()
+			redisCache, redisPool = nil, nil
+		}
+	}},
+	{"badger", func(t *testing.T, c *Celeritas) func() {
+		badgerCache = c.createBadgerCache()
+		badgerConn = badgerCache.Conn
+		c.Cache = badgerCache
+
+		return func() {
+			_ = badgerConn.Close()
+			_ = badgerJobsConn.Close()
+			badgerCache, badgerConn, badgerJobsConn = nil, nil, nil
+		}
+	}},
+}
+
+func TestCeleritas_JobsSurviveEmptyCache(t *testing.T) {
+	for _, e := range jobsCacheTests {
+		c := &Celeritas{RootPath: t.TempDir()}
+		c.config.Jobs = JobsConfig{Backend: e.name, Workers: 1, MaxAttempts: 1, Timeout: time.Minute}
+		done := e.setup(t, c)
+
+		c.Jobs = c.createJobQueue()
+		id, err := c.Jobs.Dispatch("test", "payload")
+		if err != nil {
+			t.Fatal(e.name, err)
+		}
+
+		_ = c.Cache.Set("key", "value")
+		if err := c.Cache.Empty(); err != nil {
+			t.Fatal(e.name, err)
+		}
+
+		if ok, _ := c.Cache.Has("key"); ok {
+			t.Errorf("%s: expected the cache to be empty", e.name)
+		}
+
+		job, err := c.Jobs.Backend.Pop(jobs.DefaultQueue, time.Minute)
+		if err != nil {
+			t.Fatal(e.name, err)
+		}
+
+		if job == nil || job.ID != id {
+			t.Errorf("%s: expected the job to survive emptying the cache, got %v", e.name, job)
+		}
+
+		done()
+	}
+}
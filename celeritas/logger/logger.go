@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+type Options struct {
+	Level      string // debug, info, warn or error
+	Format     string // text or json
+	Output     string // stdout, file or both
+	File       string // path of the log file when writing to file
+	MaxSize    int    // maximum size of the log file in megabytes before it is rotated
+	MaxBackups int    // number of rotated log files to keep
+}
+
+type contextKey struct{}
+
+// New creates a structured logger from the options. The returned io.Closer must be
+// closed on shutdown to release the log file, if any
+func New(o Options) (*slog.Logger, io.Closer, error) {
+	level, err := ParseLevel(o.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var out io.Writer
+	var closer io.Closer = nopCloser{}
+
+	switch strings.ToLower(o.Output) {
+	case "", "stdout":
+		out = os.Stdout
+	case "file", "both":
+		f := &RotatingFile{
+			Filename:   o.File,
+			MaxSize:    int64(o.MaxSize) * 1024 * 1024,
+			MaxBackups: o.MaxBackups,
+		}
+		out, closer = f, f
+		if strings.ToLower(o.Output) == "both" {
+			out = io.MultiWriter(os.Stdout, f)
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown log output %q, only stdout, file or both accepted", o.Output)
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch strings.ToLower(o.Format) {
+	case "", "text":
+		handler = slog.NewTextHandler(out, handlerOpts)
+	case "json":
+		handler = slog.NewJSONHandler(out, handlerOpts)
+	default:
+		return nil, nil, fmt.Errorf("unknown log format %q, only text or json accepted", o.Format)
+	}
+
+	return slog.New(handler), closer, nil
+}
+
+// ParseLevel converts a level name to a slog.Level. An empty name is treated as info
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if name == "" {
+		return slog.LevelInfo, nil
+	}
+
+	err := level.UnmarshalText([]byte(name))
+	if err != nil {
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q, only debug, info, warn or error accepted", name)
+	}
+
+	return level, nil
+}
+
+// WithContext returns a copy of ctx that carries the logger
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx, e.g. the request-scoped logger added by
+// the Celeritas request logging middleware. If there is none, the default logger is returned
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error {
+	return nil
+}
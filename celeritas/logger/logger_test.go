@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var levelTests = []struct {
+	name          string
+	level         string
+	expected      slog.Level
+	errorExpected bool
+}{
+	{"empty", "", slog.LevelInfo, false},
+	{"debug", "debug", slog.LevelDebug, false},
+	{"warn", "WARN", slog.LevelWarn, false},
+	{"error", "error", slog.LevelError, false},
+	{"invalid", "loud", slog.LevelInfo, true},
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, e := range levelTests {
+		level, err := ParseLevel(e.level)
+		if e.errorExpected && err == nil {
+			t.Errorf("%s: expected an error", e.name)
+		}
+		if !e.errorExpected && err != nil {
+			t.Errorf("%s: unexpected error: %s", e.name, err)
+		}
+		if level != e.expected {
+			t.Errorf("%s: expected %s but got %s", e.name, e.expected, level)
+		}
+	}
+}
+
+func TestNew_JSONFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "logs", "app.log")
+
+	l, closer, err := New(Options{Level: "info", Format: "json", Output: "file", File: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Debug("not written")
+	l.Info("hello", slog.String("foo", "bar"))
+	_ = closer.Close()
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var line map[string]interface{}
+	err = json.Unmarshal(content, &line)
+	if err != nil {
+		t.Fatal("expected exactly one json log line:", err)
+	}
+
+	if line["msg"] != "hello" || line["foo"] != "bar" {
+		t.Error("wrong log line written:", string(content))
+	}
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	if _, _, err := New(Options{Format: "xml"}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+
+	if _, _, err := New(Options{Output: "syslog"}); err == nil {
+		t.Error("expected an error for an unknown output")
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if FromContext(context.Background()) != slog.Default() {
+		t.Error("expected the default logger for a context without one")
+	}
+
+	l := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	ctx := WithContext(context.Background(), l)
+	if FromContext(ctx) != l {
+		t.Error("did not get the logger stored in the context")
+	}
+}
@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// RotatingFile is an io.Writer that writes to a file, and rotates it once it grows beyond
+// MaxSize bytes. Rotated files are renamed to Filename.1, Filename.2, etc. with .1 being
+// the most recent, and only MaxBackups of them are kept
+type RotatingFile struct {
+	Filename   string
+	MaxSize    int64
+	MaxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// Write writes p to the log file, rotating it first if p would make it too large
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if f.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.MaxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err
+}
+
+// Close closes the log file
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+
+	return err
+}
+
+func (f *RotatingFile) open() error {
+	err := os.MkdirAll(filepath.Dir(f.Filename), 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+
+	return nil
+}
+
+func (f *RotatingFile) rotate() error {
+	err := f.file.Close()
+	if err != nil {
+		return err
+	}
+	f.file = nil
+
+	if f.MaxBackups > 0 {
+		// shift existing backups up by one, dropping the oldest
+		_ = os.Remove(f.backupName(f.MaxBackups))
+		for i := f.MaxBackups - 1; i >= 1; i-- {
+			_ = os.Rename(f.backupName(i), f.backupName(i+1))
+		}
+
+		err = os.Rename(f.Filename, f.backupName(1))
+	} else {
+		err = os.Remove(f.Filename)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return f.open()
+}
+
+func (f *RotatingFile) backupName(n int) string {
+	return fmt.Sprintf("%s.%d", f.Filename, n)
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile_Write(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+
+	f := &RotatingFile{
+		Filename:   file,
+		MaxSize:    10,
+		MaxBackups: 2,
+	}
+	defer f.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := f.Write([]byte(line))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := map[string]string{
+		file:        "fourth\n",
+		file + ".1": "third\n",
+		file + ".2": "second\n",
+	}
+
+	for name, content := range expected {
+		got, err := os.ReadFile(name)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(got) != content {
+			t.Errorf("%s: expected %q but got %q", filepath.Base(name), content, got)
+		}
+	}
+
+	// only MaxBackups rotated files are kept
+	if _, err := os.Stat(file + ".3"); !os.IsNotExist(err) {
+		t.Error("too many backups kept")
+	}
+}
+
+func TestRotatingFile_Append(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	_ = os.WriteFile(file, []byte("existing\n"), 0644)
+
+	f := &RotatingFile{Filename: file, MaxSize: 1024}
+	_, _ = f.Write([]byte("new\n"))
+	_ = f.Close()
+
+	got, _ := os.ReadFile(file)
+	if !strings.HasPrefix(string(got), "existing\n") {
+		t.Error("existing log file was not appended to")
+	}
+}
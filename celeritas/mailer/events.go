@@ -0,0 +1,20 @@
+package mailer
+
+// Sent is published when a message was sent
+type Sent struct {
+	To       string
+	Subject  string
+	Template string
+}
+
+func (Sent) Name() string { return "mail.sent" }
+
+// Failed is published when a message could not be sent
+type Failed struct {
+	To       string
+	Subject  string
+	Template string
+	Error    error
+}
+
+func (Failed) Name() string { return "mail.failed" }
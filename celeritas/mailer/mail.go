@@ -2,20 +2,33 @@ package mailer
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"github.com/ainsleyclark/go-mail/drivers"
 	"github.com/ainsleyclark/go-mail/mail"
+	"github.com/fouched/celeritas/events"
 	"github.com/vanng822/go-premailer/premailer"
 	smtpmail "github.com/xhit/go-simple-mail/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"html/template"
+	"io/fs"
+	"net"
+	"net/smtp"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 )
 
 type Mail struct {
 	Domain      string
 	Templates   string
+	Files       fs.FS // the templates directory; read from Templates on disk when nil
 	Host        string
 	Port        int
 	Username    string
@@ -25,9 +38,12 @@ type Mail struct {
 	FromName    string
 	Jobs        chan Message
 	Results     chan Result
+	Done        chan struct{}
+	Quit        chan struct{} // closed by Shutdown to stop ListenForMail
 	API         string
 	APIKey      string
 	APIUrl      string
+	Events      *events.Bus
 }
 
 type Message struct {
@@ -38,6 +54,8 @@ type Message struct {
 	Template    string
 	Attachments []string
 	Data        interface{}
+
+	reply chan Result // set by Queue, receives the result of sending this message only
 }
 
 type Result struct {
@@ -45,29 +63,151 @@ type Result struct {
 	Error   error
 }
 
+// ErrShutdown is returned when queueing mail after Shutdown
+var ErrShutdown = errors.New("mailer is shut down")
+
 // ListenForMail listens to the mail channel and sends email with it receives a payload.
 // It runs continually in the background, and sends error/success messages back on the
-// Results channel.
+// channel returned by Queue, or for messages put on Jobs directly, on the Results channel,
+// dropping them when nobody reads the results and the channel is full.
+// When the Quit channel is closed, any queued messages are sent before it returns and
+// closes the Done channel (if set).
 // Note: that if api and api key are set, it will prefer using an api to send mail iso SMTP
 func (m *Mail) ListenForMail() {
 	for {
-		msg := <-m.Jobs
-		err := m.Send(msg)
-		if err != nil {
-			m.Results <- Result{false, err}
-		} else {
-			m.Results <- Result{true, nil}
+		select {
+		case msg := <-m.Jobs:
+			m.process(msg)
+		case <-m.Quit:
+			m.drain()
+			if m.Done != nil {
+				close(m.Done)
+			}
+			return
 		}
 	}
 }
 
-// Send allows sending of mail directly
+// drain sends the messages still queued on the Jobs channel
+func (m *Mail) drain() {
+	for {
+		select {
+		case msg := <-m.Jobs:
+			m.process(msg)
+		default:
+			return
+		}
+	}
+}
+
+// process sends msg and reports the result, without blocking when Results is full
+func (m *Mail) process(msg Message) {
+	res := Result{Success: true}
+	if err := m.Send(msg); err != nil {
+		res = Result{false, err}
+	}
+
+	if msg.reply != nil {
+		msg.reply <- res
+		return
+	}
+
+	select {
+	case m.Results <- res:
+	default:
+	}
+}
+
+// Queue puts msg on the Jobs channel for ListenForMail to send, and returns a channel that
+// receives the result of sending it, or returns ErrShutdown after Shutdown, e.g.
+//
+//	reply, err := app.Mail.Queue(msg)
+//	...
+//	select {
+//	case res := <-reply:
+//	case <-time.After(30 * time.Second):
+//	}
+func (m *Mail) Queue(msg Message) (<-chan Result, error) {
+	select {
+	case <-m.Quit:
+		return nil, ErrShutdown
+	default:
+	}
+
+	// buffered, so the result is never blocked on a caller that stopped waiting
+	msg.reply = make(chan Result, 1)
+
+	select {
+	case m.Jobs <- msg:
+		return msg.reply, nil
+	case <-m.Quit:
+		return nil, ErrShutdown
+	}
+}
+
+// Shutdown stops accepting new mail and waits for ListenForMail to finish sending
+// everything already queued, or for the context to expire. The Jobs channel is left open,
+// so that sending on it after a timed out shutdown does not panic
+func (m *Mail) Shutdown(ctx context.Context) error {
+	if m.Quit == nil {
+		return nil
+	}
+
+	select {
+	case <-m.Quit:
+	default:
+		close(m.Quit)
+	}
+
+	if m.Done == nil {
+		return nil
+	}
+
+	select {
+	case <-m.Done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// tracer records a span for every message sent, when tracing is enabled
+var tracer = otel.Tracer("github.com/fouched/celeritas/mailer")
+
+// Send allows sending of mail directly, and publishes a Sent or Failed event
 // Note: that if api and api key are set, it will prefer using an api to send mail iso SMTP
 func (m *Mail) Send(msg Message) error {
+	return m.SendContext(context.Background(), msg)
+}
+
+// SendContext sends mail like Send, as part of the trace in ctx, if any
+func (m *Mail) SendContext(ctx context.Context, msg Message) error {
+	transport := "smtp"
 	if len(m.API) > 0 && len(m.APIKey)&len(m.APIUrl) > 0 && m.API != "smtp" {
-		return m.SendAPIMessage(msg)
+		transport = m.API
+	}
+
+	ctx, span := tracer.Start(ctx, "mail send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("mail.transport", transport), attribute.String("mail.template", msg.Template)))
+	defer span.End()
+
+	var err error
+	if transport != "smtp" {
+		err = m.SendAPIMessage(msg)
+	} else {
+		err = m.SendSMTPMessage(msg)
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		m.Events.Publish(ctx, Failed{To: msg.To, Subject: msg.Subject, Template: msg.Template, Error: err})
+	} else {
+		m.Events.Publish(ctx, Sent{To: msg.To, Subject: msg.Subject, Template: msg.Template})
 	}
-	return m.SendSMTPMessage(msg)
+
+	return err
 }
 
 // SendAPIMessage allows sending of mail directly via API
@@ -228,6 +368,37 @@ func (m *Mail) SendSMTPMessage(msg Message) error {
 	return nil
 }
 
+// Ping connects to the SMTP server and issues a NOOP command, to check that mail can be sent
+func (m *Mail) Ping(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(m.Host, strconv.Itoa(m.Port)))
+	if err != nil {
+		return err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if m.Encryption == "ssl" {
+		conn = tls.Client(conn, &tls.Config{ServerName: m.Host})
+	}
+
+	client, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	err = client.Noop()
+	if err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
 func (m *Mail) sanitizeMessage(msg Message) Message {
 
 	if msg.From == "" {
@@ -254,10 +425,16 @@ func (m *Mail) getEncryption(e string) smtpmail.Encryption {
 	}
 }
 
-func (m *Mail) buildHTMLMessage(msg Message) (string, error) {
-	templateToRender := fmt.Sprintf("%s/%s.html.tmpl", m.Templates, msg.Template)
+// templates returns the directory the templates are read from
+func (m *Mail) templates() fs.FS {
+	if m.Files != nil {
+		return m.Files
+	}
+	return os.DirFS(m.Templates)
+}
 
-	t, err := template.New("email-html").ParseFiles(templateToRender)
+func (m *Mail) buildHTMLMessage(msg Message) (string, error) {
+	t, err := template.New("email-html").ParseFS(m.templates(), msg.Template+".html.tmpl")
 	if err != nil {
 		return "", err
 	}
@@ -277,9 +454,7 @@ func (m *Mail) buildHTMLMessage(msg Message) (string, error) {
 }
 
 func (m *Mail) buildPlainTextMessage(msg Message) (string, error) {
-	templateToRender := fmt.Sprintf("%s/%s.text.tmpl", m.Templates, msg.Template)
-
-	t, err := template.New("email-text").ParseFiles(templateToRender)
+	t, err := template.New("email-text").ParseFS(m.templates(), msg.Template+".text.tmpl")
 	if err != nil {
 		return "", err
 	}
@@ -1,8 +1,10 @@
 package mailer
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 )
 
 var msg = Message{
@@ -38,6 +40,27 @@ func TestMail_SendUsingChan(t *testing.T) {
 	msg.To = "you@there.com"
 }
 
+func TestMail_Queue(t *testing.T) {
+	// leave a stale result, which must not be mistaken for the result of the queued message
+	mailer.Jobs <- Message{To: "not_an_email"}
+
+	reply, err := mailer.Queue(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case res := <-reply:
+		if res.Error != nil {
+			t.Error("failed to send queued mail:", res.Error)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("no result for the queued mail")
+	}
+
+	<-mailer.Results
+}
+
 func TestMail_SendAPIMessage(t *testing.T) {
 	mailer.API = "unknown"
 	mailer.APIKey = "abc123"
@@ -86,3 +109,27 @@ func TestMail_Send(t *testing.T) {
 	mailer.APIKey = ""
 	mailer.APIUrl = ""
 }
+
+func TestMail_Shutdown(t *testing.T) {
+	m := Mail{
+		Jobs:    make(chan Message, 1),
+		Results: make(chan Result, 1),
+		Done:    make(chan struct{}),
+		Quit:    make(chan struct{}),
+	}
+
+	// nothing is listening, so the shutdown times out
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := m.Shutdown(ctx); !errors.Is(err, context.Canceled) {
+		t.Error("expected the shutdown to time out, got", err)
+	}
+
+	if _, err := m.Queue(msg); !errors.Is(err, ErrShutdown) {
+		t.Error("expected ErrShutdown queueing mail after shutdown, got", err)
+	}
+
+	// sending on the channel directly must not panic
+	m.Jobs <- msg
+}
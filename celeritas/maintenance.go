@@ -0,0 +1,261 @@
+package celeritas
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/CloudyKit/jet/v6"
+	"github.com/fouched/celeritas/logger"
+	"github.com/fouched/celeritas/render"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maintenanceFile is where `celeritas down` writes the maintenance marker, relative to the
+// application root
+const maintenanceFile = "tmp/maintenance.json"
+
+// maintenanceCookie holds the hash of the secret for clients that visited the bypass URL
+const maintenanceCookie = "celeritas_maintenance"
+
+const maintenancePage = `<!doctype html>
+<html lang="en">
+<head><meta charset="utf-8"><title>Service Unavailable</title></head>
+<body>
+<h1>Service Unavailable</h1>
+<p>We are down for maintenance. Please try again later.</p>
+</body>
+</html>
+`
+
+// Maintenance describes the maintenance window started by `celeritas down`
+type Maintenance struct {
+	Since  time.Time `json:"since"`
+	Retry  int       `json:"retry,omitempty"`  // seconds clients are asked to wait, sent as Retry-After
+	Secret string    `json:"secret,omitempty"` // visiting /<secret> sets a cookie that bypasses maintenance mode
+	Allow  []string  `json:"allow,omitempty"`  // IPs or CIDRs that are never shown the maintenance page
+}
+
+// allows reports whether ip is on the allowlist
+func (m *Maintenance) allows(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, entry := range m.Allow {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cookieValue returns the value of the bypass cookie, which changes with the secret, so that
+// going down with a new secret invalidates earlier cookies
+func (m *Maintenance) cookieValue() string {
+	sum := sha256.Sum256([]byte("celeritas-maintenance:" + m.Secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// maintenanceState caches the marker, so it is only read again when it changes
+type maintenanceState struct {
+	mu      sync.Mutex
+	modTime time.Time
+	current *Maintenance
+}
+
+// Down puts the application in maintenance mode by writing the marker, which running
+// instances that share the application root pick up on their next request
+func (c *Celeritas) Down(m Maintenance) error {
+	for _, entry := range m.Allow {
+		_, _, cidrErr := net.ParseCIDR(entry)
+		if net.ParseIP(entry) == nil && cidrErr != nil {
+			return fmt.Errorf("%q is not an IP address or CIDR", entry)
+		}
+	}
+
+	if strings.Contains(m.Secret, "/") {
+		return errors.New("the maintenance secret cannot contain /")
+	}
+
+	if m.Since.IsZero() {
+		m.Since = time.Now()
+	}
+
+	data, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	file := filepath.Join(c.RootPath, maintenanceFile)
+	err = os.MkdirAll(filepath.Dir(file), 0755)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so instances never read a half written marker
+	tmp := file + ".tmp"
+	err = os.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, file)
+}
+
+// Up ends maintenance mode by removing the marker
+func (c *Celeritas) Up() error {
+	err := os.Remove(filepath.Join(c.RootPath, maintenanceFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// InMaintenance returns the current maintenance window, or nil if the application is up
+func (c *Celeritas) InMaintenance() (*Maintenance, error) {
+	file := filepath.Join(c.RootPath, maintenanceFile)
+
+	info, err := os.Stat(file)
+	if errors.Is(err, os.ErrNotExist) {
+		c.maintenance.mu.Lock()
+		c.maintenance.current, c.maintenance.modTime = nil, time.Time{}
+		c.maintenance.mu.Unlock()
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	c.maintenance.mu.Lock()
+	defer c.maintenance.mu.Unlock()
+
+	if c.maintenance.current != nil && info.ModTime().Equal(c.maintenance.modTime) {
+		return c.maintenance.current, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Maintenance
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return nil, fmt.Errorf("invalid maintenance marker %s: %w", file, err)
+	}
+
+	c.maintenance.current, c.maintenance.modTime = &m, info.ModTime()
+	return &m, nil
+}
+
+// MaintenanceMode answers requests with 503 Service Unavailable while the application is down,
+// using the views/errors/503.jet template if there is one. Clients on the allowlist are served
+// as usual, and so are clients that visited /<secret>, which sets a cookie and redirects to /
+func (c *Celeritas) MaintenanceMode(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m, err := c.InMaintenance()
+		if err != nil {
+			// rather serve the request than take the application down because of a broken marker
+			logger.FromContext(r.Context()).Error("could not read maintenance marker", "error", err)
+		}
+
+		if m == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if m.Secret != "" && r.URL.Path == "/"+m.Secret {
+			http.SetCookie(w, &http.Cookie{
+				Name:     maintenanceCookie,
+				Value:    m.cookieValue(),
+				Path:     "/",
+				Domain:   c.config.Cookie.Domain,
+				Expires:  time.Now().Add(12 * time.Hour),
+				HttpOnly: true,
+				Secure:   c.config.Cookie.Secure,
+				SameSite: http.SameSiteLaxMode,
+			})
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+			return
+		}
+
+		if m.Secret != "" {
+			if cookie, err := r.Cookie(maintenanceCookie); err == nil &&
+				subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(m.cookieValue())) == 1 {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		if m.allows(clientIP(r)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		c.serviceUnavailable(w, r, m)
+	})
+}
+
+// serviceUnavailable tells the client that the application is down, in JSON or HTML
+func (c *Celeritas) serviceUnavailable(w http.ResponseWriter, r *http.Request, m *Maintenance) {
+	if m.Retry > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(m.Retry))
+	}
+	w.Header().Set("Cache-Control", "no-store")
+
+	if wantsJSON(r) {
+		payload := struct {
+			Error   bool   `json:"error"`
+			Message string `json:"message"`
+		}{
+			Error:   true,
+			Message: "down for maintenance",
+		}
+		_ = c.WriteJSON(w, http.StatusServiceUnavailable, payload)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	if c.JetViews != nil {
+		if t, err := c.JetViews.GetTemplate("errors/503.jet"); err == nil {
+			vars := make(jet.VarMap)
+			vars.Set("retry", m.Retry)
+			vars.Set("since", m.Since)
+			vars.Set("cspNonce", render.Nonce(r.Context()))
+
+			w.WriteHeader(http.StatusServiceUnavailable)
+			if err := t.Execute(w, vars, nil); err != nil {
+				logger.FromContext(r.Context()).Error("could not render maintenance page", "error", err)
+			}
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusServiceUnavailable)
+	_, _ = fmt.Fprint(w, maintenancePage)
+}
+
+// clientIP returns the IP address of the client. Use it after middleware.RealIP, so that it
+// is the address of the client rather than of a proxy in front of the application
+func clientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
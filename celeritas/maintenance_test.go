@@ -0,0 +1,129 @@
+package celeritas
+
+import (
+	"github.com/CloudyKit/jet/v6"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var maintenanceTests = []struct {
+	name       string
+	path       string
+	remoteAddr string
+	cookie     bool
+	expected   int
+}{
+	{"down", "/", "192.0.2.1:1234", false, http.StatusServiceUnavailable},
+	{"allowed-ip", "/", "203.0.113.7:1234", false, http.StatusOK},
+	{"allowed-cidr", "/", "10.1.2.3:1234", false, http.StatusOK},
+	{"secret", "/let-me-in", "192.0.2.1:1234", false, http.StatusSeeOther},
+	{"bypass-cookie", "/", "192.0.2.1:1234", true, http.StatusOK},
+}
+
+func TestCeleritas_MaintenanceMode(t *testing.T) {
+	c := Celeritas{RootPath: t.TempDir()}
+
+	err := c.Down(Maintenance{Retry: 60, Secret: "let-me-in", Allow: []string{"203.0.113.7", "10.0.0.0/8"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := c.MaintenanceMode(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// visit the bypass url to get the cookie
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/let-me-in", nil))
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatal("expected the bypass url to set a cookie")
+	}
+
+	for _, e := range maintenanceTests {
+		r := httptest.NewRequest("GET", e.path, nil)
+		r.RemoteAddr = e.remoteAddr
+		if e.cookie {
+			r.AddCookie(cookies[0])
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != e.expected {
+			t.Errorf("%s: expected status %d, got %d", e.name, e.expected, w.Code)
+		}
+		if w.Code == http.StatusServiceUnavailable && w.Header().Get("Retry-After") != "60" {
+			t.Errorf("%s: wrong Retry-After %q", e.name, w.Header().Get("Retry-After"))
+		}
+	}
+
+	// a new secret invalidates the cookie
+	err = c.Down(Maintenance{Secret: "new-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Error("expected the old bypass cookie to be rejected, got", w.Code)
+	}
+
+	err = c.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusOK {
+		t.Error("expected requests to be served after up, got", w.Code)
+	}
+}
+
+func TestCeleritas_MaintenancePage(t *testing.T) {
+	root := t.TempDir()
+	err := os.MkdirAll(filepath.Join(root, "views", "errors"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(root, "views", "errors", "503.jet"), []byte("back in {{ retry }} seconds"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := Celeritas{RootPath: root, JetViews: jet.NewSet(jet.NewOSFileSystemLoader(filepath.Join(root, "views")))}
+	err = c.Down(Maintenance{Retry: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := c.MaintenanceMode(http.NotFoundHandler())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "back in 30 seconds") {
+		t.Errorf("expected the custom maintenance page, got %d: %s", w.Code, w.Body.String())
+	}
+
+	r := httptest.NewRequest("GET", "/api/users", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if !strings.Contains(w.Header().Get("Content-Type"), "application/json") {
+		t.Error("expected a JSON response for the API, got", w.Header().Get("Content-Type"))
+	}
+}
+
+func TestCeleritas_DownInvalidAllow(t *testing.T) {
+	c := Celeritas{RootPath: t.TempDir()}
+
+	err := c.Down(Maintenance{Allow: []string{"office"}})
+	if err == nil {
+		t.Error("expected an error for an invalid allowlist entry")
+	}
+}
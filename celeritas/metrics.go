@@ -0,0 +1,64 @@
+package celeritas
+
+import (
+	"context"
+	"crypto/subtle"
+	"github.com/fouched/celeritas/cache"
+	"github.com/fouched/celeritas/events"
+	"github.com/fouched/celeritas/mailer"
+	"github.com/fouched/celeritas/metrics"
+	"net/http"
+)
+
+// createMetrics creates the metrics registry, and counts cache hits and misses and mail sent
+// and failed by listening to their events
+func (c *Celeritas) createMetrics() *metrics.Metrics {
+	m := metrics.New()
+
+	events.Listen(c.Events, func(ctx context.Context, e cache.Hit) error {
+		m.CacheHit()
+		return nil
+	})
+	events.Listen(c.Events, func(ctx context.Context, e cache.Miss) error {
+		m.CacheMiss()
+		return nil
+	})
+	events.Listen(c.Events, func(ctx context.Context, e mailer.Sent) error {
+		m.MailSent()
+		return nil
+	})
+	events.Listen(c.Events, func(ctx context.Context, e mailer.Failed) error {
+		m.MailFailed()
+		return nil
+	})
+
+	return m
+}
+
+// HTTPMetrics serves the metrics at METRICS_PATH, and counts and times all other requests.
+// It does nothing when metrics are disabled
+func (c *Celeritas) HTTPMetrics(next http.Handler) http.Handler {
+	if c.config.Metrics.Disabled || c.Metrics == nil {
+		return next
+	}
+
+	endpoint := c.Metrics.Handler()
+	instrumented := c.Metrics.Middleware(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != c.config.Metrics.Path || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+			instrumented.ServeHTTP(w, r)
+			return
+		}
+
+		if token := c.config.Metrics.Token; token != "" {
+			auth := []byte(r.Header.Get("Authorization"))
+			if subtle.ConstantTimeCompare(auth, []byte("Bearer "+token)) != 1 {
+				c.ErrorUnauthorized(w)
+				return
+			}
+		}
+
+		endpoint.ServeHTTP(w, r)
+	})
+}
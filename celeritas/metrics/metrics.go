@@ -0,0 +1,164 @@
+package metrics
+
+import (
+	"database/sql"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics collects the framework's metrics, and those the application adds to Registry, and
+// serves them in the Prometheus text format
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	requests    *prometheus.CounterVec
+	duration    *prometheus.HistogramVec
+	cacheHits   prometheus.Counter
+	cacheMisses prometheus.Counter
+	mailSent    prometheus.Counter
+	mailFailed  prometheus.Counter
+}
+
+// New creates the registry with the HTTP, cache and mail metrics, and the Go runtime and
+// process metrics
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Number of HTTP requests by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Latency of HTTP requests by method and route pattern.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		cacheHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cache_hits_total",
+			Help: "Number of cache reads that found the key.",
+		}),
+		cacheMisses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cache_misses_total",
+			Help: "Number of cache reads that did not find the key.",
+		}),
+		mailSent: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "mail_sent_total",
+			Help: "Number of mail messages sent.",
+		}),
+		mailFailed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "mail_failed_total",
+			Help: "Number of mail messages that could not be sent.",
+		}),
+	}
+
+	m.Registry.MustRegister(
+		m.requests, m.duration, m.cacheHits, m.cacheMisses, m.mailSent, m.mailFailed,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{Registry: m.Registry})
+}
+
+// Middleware counts requests and measures their latency by route pattern, e.g. /users/{id},
+// so that the number of series does not grow with the number of URLs. Requests that match no
+// route are counted as "unmatched"
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		completed := false
+		defer func() {
+			status := ww.Status()
+			if !completed {
+				// the handler panicked, and the recoverer will respond with an error
+				status = http.StatusInternalServerError
+			} else if status == 0 {
+				status = http.StatusOK
+			}
+
+			route := "unmatched"
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+
+			m.requests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+			m.duration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+		}()
+
+		next.ServeHTTP(ww, r)
+		completed = true
+	})
+}
+
+// CacheHit counts a cache read that found the key
+func (m *Metrics) CacheHit() { m.cacheHits.Inc() }
+
+// CacheMiss counts a cache read that did not find the key
+func (m *Metrics) CacheMiss() { m.cacheMisses.Inc() }
+
+// MailSent counts a message that was sent
+func (m *Metrics) MailSent() { m.mailSent.Inc() }
+
+// MailFailed counts a message that could not be sent
+func (m *Metrics) MailFailed() { m.mailFailed.Inc() }
+
+// CollectDB adds the connection pool statistics of db, i.e. db.Stats(), labelled with name
+func (m *Metrics) CollectDB(db *sql.DB, name string) {
+	m.Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// CollectQueueSize adds a gauge with the number of jobs on each of queues, which is read
+// from size whenever the metrics are scraped
+func (m *Metrics) CollectQueueSize(queues []string, size func(queue string) (int, error)) {
+	for _, queue := range queues {
+		m.Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "jobs_queue_size",
+			Help:        "Number of jobs on the queue, including delayed and reserved jobs.",
+			ConstLabels: prometheus.Labels{"queue": queue},
+		}, func() float64 {
+			n, err := size(queue)
+			if err != nil {
+				return -1
+			}
+			return float64(n)
+		}))
+	}
+}
+
+// NewCounter registers a counter for the application, e.g.
+//
+//	orders := app.Metrics.NewCounter("orders_total", "Number of orders placed.", "country")
+//	orders.WithLabelValues("ZA").Inc()
+func (m *Metrics) NewCounter(name, help string, labels ...string) *prometheus.CounterVec {
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labels)
+	m.Registry.MustRegister(c)
+	return c
+}
+
+// NewGauge registers a gauge for the application
+func (m *Metrics) NewGauge(name, help string, labels ...string) *prometheus.GaugeVec {
+	g := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, labels)
+	m.Registry.MustRegister(g)
+	return g
+}
+
+// NewHistogram registers a histogram for the application, with the default buckets if
+// buckets is nil
+func (m *Metrics) NewHistogram(name, help string, buckets []float64, labels ...string) *prometheus.HistogramVec {
+	h := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: help, Buckets: buckets}, labels)
+	m.Registry.MustRegister(h)
+	return h
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// scrape returns the metrics in the text format
+func scrape(t *testing.T, m *Metrics) string {
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, err := io.ReadAll(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestMetrics_Middleware(t *testing.T) {
+	m := New()
+
+	mux := chi.NewRouter()
+	mux.Use(middleware.Recoverer)
+	mux.Use(m.Middleware)
+	mux.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/nowhere", "/panic"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	out := scrape(t, m)
+	for _, expected := range []string{
+		`http_requests_total{method="GET",route="/users/{id}",status="200"} 2`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`http_requests_total{method="GET",route="/panic",status="500"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/users/{id}"} 2`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Error("missing", expected)
+		}
+	}
+}
+
+func TestMetrics_Collectors(t *testing.T) {
+	m := New()
+
+	m.CacheHit()
+	m.CacheMiss()
+	m.CacheMiss()
+	m.MailSent()
+	m.MailFailed()
+	m.CollectQueueSize([]string{"default", "mail"}, func(queue string) (int, error) {
+		return len(queue), nil
+	})
+	orders := m.NewCounter("orders_total", "Number of orders.", "country")
+	orders.WithLabelValues("ZA").Add(3)
+
+	out := scrape(t, m)
+	for _, expected := range []string{
+		"cache_hits_total 1",
+		"cache_misses_total 2",
+		"mail_sent_total 1",
+		"mail_failed_total 1",
+		`jobs_queue_size{queue="default"} 7`,
+		`jobs_queue_size{queue="mail"} 4`,
+		`orders_total{country="ZA"} 3`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Error("missing", expected)
+		}
+	}
+}
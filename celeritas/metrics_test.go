@@ -0,0 +1,52 @@
+package celeritas
+
+import (
+	"context"
+	"github.com/fouched/celeritas/cache"
+	"github.com/fouched/celeritas/events"
+	"github.com/fouched/celeritas/metrics"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCeleritas_HTTPMetrics(t *testing.T) {
+	cfg, _ := ReadConfig(map[string]string{"METRICS_TOKEN": "secret"})
+	c := Celeritas{config: cfg, Events: events.New(slog.New(slog.NewTextHandler(io.Discard, nil)), 1, 1)}
+	c.Metrics = c.createMetrics()
+
+	// cache reads are counted through their events
+	c.Events.Publish(context.Background(), cache.Hit{Key: "foo"})
+
+	handler := c.HTTPMetrics(http.NotFoundHandler())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Error("expected metrics to require the token, got", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/metrics", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "cache_hits_total 1") {
+		t.Errorf("wrong metrics response %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCeleritas_HTTPMetricsDisabled(t *testing.T) {
+	cfg, _ := ReadConfig(map[string]string{"METRICS_ENABLED": "false"})
+	c := Celeritas{config: cfg, Metrics: metrics.New()}
+
+	w := httptest.NewRecorder()
+	c.HTTPMetrics(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Error("expected no metrics endpoint when disabled, got", w.Code)
+	}
+}
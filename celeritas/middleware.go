@@ -1,9 +1,13 @@
 package celeritas
 
 import (
+	"github.com/fouched/celeritas/logger"
+	"github.com/fouched/celeritas/tracing"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/justinas/nosurf"
+	"log/slog"
 	"net/http"
-	"strconv"
+	"time"
 )
 
 func (c *Celeritas) SessionLoad(next http.Handler) http.Handler {
@@ -11,9 +15,42 @@ func (c *Celeritas) SessionLoad(next http.Handler) http.Handler {
 	return c.Session.LoadAndSave(next)
 }
 
+// RequestLogger adds a logger carrying the request id, method and path to the request
+// context, where handlers can get it with logger.FromContext. When tracing is enabled, it
+// carries the trace id as well. Once the request completes, it is logged with its status
+// and latency
+func (c *Celeritas) RequestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		attrs := []any{
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		}
+		if traceID := tracing.TraceID(r.Context()); traceID != "" {
+			attrs = append(attrs, slog.String("trace_id", traceID))
+		}
+
+		l := c.Logger.With(attrs...)
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(logger.WithContext(r.Context(), l)))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		l.Info("request completed",
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+		)
+	})
+}
+
 func (c *Celeritas) NoSurf(next http.Handler) http.Handler {
 	csrfHandler := nosurf.New(next)
-	secure, _ := strconv.ParseBool(c.config.cookie.secure)
 
 	// to allow some URLS
 	csrfHandler.ExemptGlob("/api/*")
@@ -34,9 +71,9 @@ func (c *Celeritas) NoSurf(next http.Handler) http.Handler {
 	csrfHandler.SetBaseCookie(http.Cookie{
 		HttpOnly: true,
 		Path:     "/",
-		Secure:   secure,
+		Secure:   c.config.Cookie.Secure,
 		SameSite: http.SameSiteStrictMode,
-		Domain:   c.config.cookie.domain,
+		Domain:   c.config.Cookie.Domain,
 	})
 
 	return csrfHandler
@@ -0,0 +1,43 @@
+package celeritas
+
+import (
+	"bytes"
+	"github.com/fouched/celeritas/logger"
+	"github.com/go-chi/chi/v5/middleware"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCeleritas_RequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	c := Celeritas{Logger: slog.New(slog.NewTextHandler(&buf, nil))}
+
+	handler := middleware.RequestID(c.RequestLogger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.FromContext(r.Context()).Info("inside handler")
+		w.WriteHeader(http.StatusTeapot)
+	})))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/some-url", nil)
+	handler.ServeHTTP(w, r)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines but got %d: %s", len(lines), buf.String())
+	}
+
+	for _, line := range lines {
+		for _, attr := range []string{"request_id=", "method=GET", "path=/some-url"} {
+			if !strings.Contains(line, attr) {
+				t.Errorf("log line missing %s: %s", attr, line)
+			}
+		}
+	}
+
+	if !strings.Contains(lines[1], "status=418") || !strings.Contains(lines[1], "latency=") {
+		t.Error("completed request not logged with status and latency:", lines[1])
+	}
+}
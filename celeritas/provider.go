@@ -0,0 +1,77 @@
+package celeritas
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// Provider extends Celeritas with services, routes, middleware, template functions, commands
+// and shutdown hooks, without having to change the framework itself.
+//
+// Register is called near the end of New, once the database, cache, session, renderer and
+// mailer exist, in the order the providers were added. This is the place to replace or wrap
+// those services, add middleware to Routes, add template functions and commands, and add
+// other providers.
+//
+// Boot is called once every provider is registered and the application has set up its own
+// routes, right before the application starts serving requests or runs a command. This is
+// the place to add routes, since chi does not allow middleware to be added after routes.
+//
+// Shutdown is called during a graceful shutdown, in the reverse order the providers were added
+type Provider interface {
+	Register(c *Celeritas) error
+	Boot(c *Celeritas) error
+	Shutdown(ctx context.Context) error
+}
+
+// AddProvider adds providers to the application. Providers must be added before New (or
+// NewWithConfig) finishes, i.e. before calling New, or from the Register method of another provider
+func (c *Celeritas) AddProvider(providers ...Provider) {
+	c.providers = append(c.providers, providers...)
+}
+
+// registerProviders calls Register on every provider, including providers that are added while registering
+func (c *Celeritas) registerProviders() error {
+	for i := 0; i < len(c.providers); i++ {
+		p := c.providers[i]
+		if err := p.Register(c); err != nil {
+			return fmt.Errorf("register provider %T: %w", p, err)
+		}
+	}
+
+	c.providersRegistered = true
+
+	return nil
+}
+
+// Boot boots the providers. It is called by ListenAndServe and RunCommand, so applications
+// only need to call it when they serve Routes themselves, e.g. in tests. Only the first call has any effect
+func (c *Celeritas) Boot() error {
+	c.bootOnce.Do(func() {
+		for _, p := range c.providers {
+			if err := p.Boot(c); err != nil {
+				c.bootErr = fmt.Errorf("boot provider %T: %w", p, err)
+				return
+			}
+		}
+	})
+
+	return c.bootErr
+}
+
+// shutdownProviders calls Shutdown on every registered provider, in reverse order
+func (c *Celeritas) shutdownProviders(ctx context.Context) []error {
+	if !c.providersRegistered {
+		return nil
+	}
+
+	var errs []error
+	for _, p := range slices.Backward(c.providers) {
+		if err := p.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("shut down provider %T: %w", p, err))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,188 @@
+package celeritas
+
+import (
+	"context"
+	"errors"
+	"github.com/CloudyKit/jet/v6"
+	"github.com/fouched/celeritas/render"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testProvider struct {
+	name  string
+	calls *[]string
+	extra Provider
+}
+
+func (p *testProvider) Register(c *Celeritas) error {
+	*p.calls = append(*p.calls, "register "+p.name)
+	if p.extra != nil {
+		c.AddProvider(p.extra)
+	}
+	return nil
+}
+
+func (p *testProvider) Boot(c *Celeritas) error {
+	*p.calls = append(*p.calls, "boot "+p.name)
+	return nil
+}
+
+func (p *testProvider) Shutdown(ctx context.Context) error {
+	*p.calls = append(*p.calls, "shutdown "+p.name)
+	return nil
+}
+
+func TestCeleritas_Providers(t *testing.T) {
+	var calls []string
+	var c Celeritas
+
+	c.AddProvider(
+		&testProvider{name: "a", calls: &calls, extra: &testProvider{name: "c", calls: &calls}},
+		&testProvider{name: "b", calls: &calls},
+	)
+
+	err := c.registerProviders()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// booting twice only boots the providers once
+	_ = c.Boot()
+	_ = c.Boot()
+
+	err = c.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"register a", "register b", "register c",
+		"boot a", "boot b", "boot c",
+		"shutdown c", "shutdown b", "shutdown a",
+	}
+
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("wrong lifecycle\nexpected: %v\ngot:      %v", expected, calls)
+	}
+}
+
+type failingProvider struct {
+	testProvider
+}
+
+func (p *failingProvider) Boot(c *Celeritas) error {
+	return errors.New("boom")
+}
+
+func TestCeleritas_Boot_Error(t *testing.T) {
+	var calls []string
+	var c Celeritas
+	c.AddProvider(&failingProvider{testProvider{name: "failing", calls: &calls}})
+
+	err := c.Boot()
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Error("expected boot error, got", err)
+	}
+}
+
+func TestCeleritas_Routes_AcceptMiddleware(t *testing.T) {
+	var c Celeritas
+	mux := c.routes().(*chi.Mux)
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Error("adding middleware after routes() panicked:", r)
+		}
+	}()
+
+	mux.Use(func(next http.Handler) http.Handler { return next })
+}
+
+func TestCeleritas_HealthProbes(t *testing.T) {
+	var c Celeritas
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := c.HealthProbes(next)
+
+	var tests = []struct {
+		method string
+		path   string
+		status int
+	}{
+		{"GET", "/healthz", http.StatusOK},
+		{"GET", "/readyz", http.StatusOK},
+		{"HEAD", "/healthz", http.StatusOK},
+		{"POST", "/healthz", http.StatusTeapot},
+		{"GET", "/", http.StatusTeapot},
+	}
+
+	for _, e := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(e.method, e.path, nil))
+
+		if w.Code != e.status {
+			t.Errorf("%s %s: expected status %d but got %d", e.method, e.path, e.status, w.Code)
+		}
+	}
+}
+
+func TestCeleritas_RunCommand(t *testing.T) {
+	var c Celeritas
+	var got []string
+
+	c.AddCommand(Command{
+		Name:        "greet",
+		Description: "says hello",
+		Run: func(c *Celeritas, args []string) error {
+			got = args
+			return nil
+		},
+	})
+
+	err := c.RunCommand([]string{"greet", "world"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, []string{"world"}) {
+		t.Error("wrong arguments passed to command:", got)
+	}
+
+	err = c.RunCommand([]string{"missing"})
+	if !errors.Is(err, ErrUnknownCommand) {
+		t.Error("expected ErrUnknownCommand, got", err)
+	}
+}
+
+func TestCeleritas_AddTemplateFunc(t *testing.T) {
+	loader := jet.NewInMemLoader()
+	loader.Set("shout.jet", `{{ shout("hi") }}`)
+
+	c := Celeritas{Render: &render.Render{JetViews: jet.NewSet(loader)}}
+	c.AddTemplateFunc("shout", strings.ToUpper)
+
+	if _, ok := c.Render.Funcs["shout"]; !ok {
+		t.Error("function not added to the Go template functions")
+	}
+
+	tmpl, err := c.Render.JetViews.GetTemplate("shout.jet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	err = tmpl.Execute(&out, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out.String() != "HI" {
+		t.Error("expected HI, got", out.String())
+	}
+}
@@ -0,0 +1,113 @@
+package celeritas
+
+import (
+	"fmt"
+	"github.com/fouched/celeritas/cache"
+	"github.com/fouched/celeritas/logger"
+	"github.com/fouched/celeritas/ratelimit"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const tooManyRequestsPage = `<!doctype html>
+<html lang="en">
+<head><meta charset="utf-8"><title>Too Many Requests</title></head>
+<body>
+<h1>Too Many Requests</h1>
+<p>You have made too many requests. Please try again in %d seconds.</p>
+</body>
+</html>
+`
+
+// RateLimit returns middleware that counts requests against limit, and answers requests over
+// it with 429 Too Many Requests. Counters are kept in the cache, so they are shared by every
+// instance of the application when the cache is redis, and in memory when there is no cache, e.g.
+//
+//	a.App.Routes.With(a.App.RateLimit(ratelimit.Limit{Name: "login", Requests: 5, Per: time.Minute})).
+//		Post("/users/login", a.Handlers.UserLoginPost)
+func (c *Celeritas) RateLimit(limit ratelimit.Limit) func(http.Handler) http.Handler {
+	limiter := &ratelimit.Limiter{Store: c.rateLimitStore(), Limit: limit}
+
+	key := limit.Key
+	if key == nil {
+		key = ratelimit.ByIP
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := limiter.Allow(key(r))
+			if err != nil {
+				// rather serve the request than fail because the cache is unavailable
+				logger.FromContext(r.Context()).Error("could not apply rate limit", "limit", limit.Name, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", result.Limit, seconds(limit.Per)))
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+
+			if !result.Allowed {
+				c.tooManyRequests(w, r, max(seconds(result.RetryAfter), 1))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitStore returns the cache to keep rate limit counters in
+func (c *Celeritas) rateLimitStore() cache.Updater {
+	if updater, ok := c.Cache.(cache.Updater); ok {
+		return updater
+	}
+
+	if c.memoryCache == nil {
+		c.memoryCache = cache.NewMemoryCache()
+	}
+	return c.memoryCache
+}
+
+// tooManyRequests tells the client to retry after retryAfter seconds, in JSON or HTML
+func (c *Celeritas) tooManyRequests(w http.ResponseWriter, r *http.Request, retryAfter int) {
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+
+	if wantsJSON(r) {
+		payload := struct {
+			Error      bool   `json:"error"`
+			Message    string `json:"message"`
+			RetryAfter int    `json:"retry_after"`
+		}{
+			Error:      true,
+			Message:    "too many requests",
+			RetryAfter: retryAfter,
+		}
+		_ = c.WriteJSON(w, http.StatusTooManyRequests, payload)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusTooManyRequests)
+	_, _ = fmt.Fprintf(w, tooManyRequestsPage, retryAfter)
+}
+
+// wantsJSON reports whether the client expects a JSON response, i.e. it accepts JSON rather
+// than HTML, or calls the API
+func wantsJSON(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		return true
+	}
+
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "application/json") && !strings.Contains(accept, "text/html")
+}
+
+// seconds rounds d up to whole seconds
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
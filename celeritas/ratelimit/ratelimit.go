@@ -0,0 +1,209 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/alexedwards/scs/v2"
+	"github.com/fouched/celeritas/cache"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Algorithm decides how requests are counted
+type Algorithm string
+
+const (
+	// TokenBucket allows bursts of up to Burst requests, and refills at Requests per Per
+	TokenBucket Algorithm = "token-bucket"
+	// SlidingWindow allows Requests in any window of length Per, weighing the requests of the
+	// previous window by how much of it still overlaps the sliding window
+	SlidingWindow Algorithm = "sliding-window"
+)
+
+// KeyFunc returns the key requests are counted by, e.g. the client's IP address
+type KeyFunc func(r *http.Request) string
+
+// Limit describes how many requests a client may make
+type Limit struct {
+	Name      string // keeps the counters of different limits apart, e.g. "login" or "api"
+	Requests  int
+	Per       time.Duration
+	Burst     int // token bucket only; defaults to Requests
+	Algorithm Algorithm
+	Key       KeyFunc // defaults to ByIP
+}
+
+// Result is the outcome of counting a request
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the client has its full allowance again
+	RetryAfter time.Duration // until the next request is allowed, if this one was not
+}
+
+// Limiter counts requests against a limit, in a store that may be shared between processes
+type Limiter struct {
+	Store cache.Updater
+	Limit Limit
+}
+
+// Allow counts a request for key, and reports whether it is within the limit
+func (l *Limiter) Allow(key string) (Result, error) {
+	if l.Limit.Requests < 1 || l.Limit.Per <= 0 {
+		return Result{}, fmt.Errorf("rate limit %q needs a positive number of requests and period", l.Limit.Name)
+	}
+
+	key = fmt.Sprintf("ratelimit:%s:%s", l.Limit.Name, key)
+	now := time.Now()
+
+	var result Result
+	var err error
+	switch l.Limit.Algorithm {
+	case TokenBucket:
+		err = l.Store.Update(key, l.Limit.Per*2, func(value []byte) ([]byte, error) {
+			return l.Limit.tokenBucket(value, now, &result)
+		})
+	case SlidingWindow, "":
+		err = l.Store.Update(key, l.Limit.Per*2, func(value []byte) ([]byte, error) {
+			return l.Limit.slidingWindow(value, now, &result)
+		})
+	default:
+		err = fmt.Errorf("unknown rate limit algorithm %q", l.Limit.Algorithm)
+	}
+
+	return result, err
+}
+
+// bucket is the state of a token bucket
+type bucket struct {
+	Tokens float64 `json:"t"`
+	At     int64   `json:"at"` // unix nanoseconds of the last refill
+}
+
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+func (l Limit) tokenBucket(value []byte, now time.Time, result *Result) ([]byte, error) {
+	capacity := float64(l.burst())
+	perToken := l.Per / time.Duration(l.Requests)
+
+	b := bucket{Tokens: capacity, At: now.UnixNano()}
+	if value != nil {
+		if err := json.Unmarshal(value, &b); err != nil {
+			return nil, err
+		}
+		elapsed := now.Sub(time.Unix(0, b.At))
+		b.Tokens = min(capacity, b.Tokens+float64(elapsed)/float64(perToken))
+		b.At = now.UnixNano()
+	}
+
+	*result = Result{Limit: l.burst()}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.Tokens) * float64(perToken))
+	}
+
+	result.Remaining = int(math.Floor(b.Tokens))
+	result.Reset = time.Duration((capacity - b.Tokens) * float64(perToken))
+
+	return json.Marshal(b)
+}
+
+// window is the state of a sliding window
+type window struct {
+	Start    int64 `json:"s"` // unix nanoseconds at which the current fixed window started
+	Current  int   `json:"c"`
+	Previous int   `json:"p"`
+}
+
+func (l Limit) slidingWindow(value []byte, now time.Time, result *Result) ([]byte, error) {
+	start := now.Truncate(l.Per)
+
+	var w window
+	if value != nil {
+		if err := json.Unmarshal(value, &w); err != nil {
+			return nil, err
+		}
+	}
+
+	switch w.Start {
+	case start.UnixNano():
+	case start.Add(-l.Per).UnixNano():
+		w = window{Start: start.UnixNano(), Previous: w.Current}
+	default:
+		w = window{Start: start.UnixNano()}
+	}
+
+	// the share of the previous window that overlaps the sliding window
+	overlap := 1 - float64(now.Sub(start))/float64(l.Per)
+	count := func() float64 {
+		return float64(w.Previous)*overlap + float64(w.Current)
+	}
+
+	*result = Result{Limit: l.Requests}
+	if count()+1 <= float64(l.Requests) {
+		w.Current++
+		result.Allowed = true
+	} else if w.Current < l.Requests {
+		// wait until enough of the previous window slid out of view
+		needed := (count() + 1 - float64(l.Requests)) / float64(w.Previous)
+		result.RetryAfter = time.Duration(needed * float64(l.Per))
+	} else {
+		// wait for the next window, and until enough of this one slid out of view
+		needed := float64(w.Current+1-l.Requests) / float64(w.Current)
+		result.RetryAfter = start.Add(l.Per).Sub(now) + time.Duration(needed*float64(l.Per))
+	}
+
+	result.Remaining = max(0, int(math.Floor(float64(l.Requests)-count())))
+	result.Reset = start.Add(l.Per).Sub(now)
+	if w.Current > 0 {
+		// the requests of the current window only slide out of view after the next window
+		result.Reset += l.Per
+	}
+
+	return json.Marshal(w)
+}
+
+// ByIP counts requests by the client's IP address. Use it after middleware.RealIP, so that
+// clients behind a proxy are told apart
+func ByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ByUser counts requests by the id of the logged in user, and by IP address for guests
+func ByUser(session *scs.SessionManager) KeyFunc {
+	return func(r *http.Request) string {
+		if session.Exists(r.Context(), "userID") {
+			return fmt.Sprintf("user:%d", session.GetInt(r.Context(), "userID"))
+		}
+		return "ip:" + ByIP(r)
+	}
+}
+
+// ByToken counts requests by the bearer token in the Authorization header, and by IP address
+// for requests without one. Tokens are hashed, so they are not stored in the cache
+func ByToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return "ip:" + ByIP(r)
+	}
+
+	sum := sha256.Sum256([]byte(token))
+	return "token:" + hex.EncodeToString(sum[:])
+}
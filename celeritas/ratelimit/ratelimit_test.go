@@ -0,0 +1,145 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/alexedwards/scs/v2"
+	"github.com/fouched/celeritas/cache"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// allow runs an algorithm at now, with the state left by the previous call
+func allow(t *testing.T, l Limit, state *[]byte, now time.Time) Result {
+	var result Result
+	var err error
+	switch l.Algorithm {
+	case TokenBucket:
+		*state, err = l.tokenBucket(*state, now, &result)
+	default:
+		*state, err = l.slidingWindow(*state, now, &result)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestLimit_TokenBucket(t *testing.T) {
+	l := Limit{Requests: 1, Per: time.Second, Burst: 3, Algorithm: TokenBucket}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var state []byte
+	for i := 0; i < 3; i++ {
+		if r := allow(t, l, &state, start); !r.Allowed || r.Remaining != 2-i {
+			t.Errorf("burst request %d: allowed %v, remaining %d", i+1, r.Allowed, r.Remaining)
+		}
+	}
+
+	r := allow(t, l, &state, start)
+	if r.Allowed || r.RetryAfter != time.Second {
+		t.Error("expected the bucket to be empty for a second, got", r.Allowed, r.RetryAfter)
+	}
+
+	if r.Reset != 3*time.Second {
+		t.Error("expected the bucket to be full after 3 seconds, got", r.Reset)
+	}
+
+	if r := allow(t, l, &state, start.Add(time.Second)); !r.Allowed {
+		t.Error("expected a token after a second")
+	}
+}
+
+func TestLimit_SlidingWindow(t *testing.T) {
+	l := Limit{Requests: 4, Per: time.Minute, Algorithm: SlidingWindow}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var state []byte
+	for i := 0; i < 4; i++ {
+		if r := allow(t, l, &state, start.Add(time.Duration(i)*time.Second)); !r.Allowed {
+			t.Error("request", i+1, "not allowed")
+		}
+	}
+
+	r := allow(t, l, &state, start.Add(30*time.Second))
+	if r.Allowed || r.Remaining != 0 {
+		t.Error("expected the fifth request in the window to be refused")
+	}
+
+	// the window is full until the next one starts, and a quarter of it slid out of view
+	if r.RetryAfter != 45*time.Second {
+		t.Error("wrong retry after:", r.RetryAfter)
+	}
+
+	// half way into the next window, half of the previous requests still count
+	if r := allow(t, l, &state, start.Add(90*time.Second)); !r.Allowed || r.Remaining != 1 {
+		t.Error("expected a request half way into the next window, got", r.Allowed, r.Remaining)
+	}
+
+	// two windows later, nothing counts anymore
+	if r := allow(t, l, &state, start.Add(4*time.Minute)); !r.Allowed || r.Remaining != 3 {
+		t.Error("expected a fresh window, got", r.Allowed, r.Remaining)
+	}
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	limiter := Limiter{
+		Store: cache.NewMemoryCache(),
+		Limit: Limit{Name: "test", Requests: 2, Per: time.Hour},
+	}
+
+	for i, allowed := range []bool{true, true, false} {
+		r, err := limiter.Allow("1.2.3.4")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Allowed != allowed {
+			t.Errorf("request %d: expected allowed %v", i+1, allowed)
+		}
+	}
+
+	// other keys have their own counter
+	if r, _ := limiter.Allow("5.6.7.8"); !r.Allowed {
+		t.Error("limit shared between keys")
+	}
+
+	limiter.Limit.Algorithm = "leaky-bucket"
+	if _, err := limiter.Allow("1.2.3.4"); err == nil {
+		t.Error("expected an error for an unknown algorithm")
+	}
+}
+
+func TestKeyFuncs(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:4321"
+
+	if ByIP(r) != "10.0.0.1" {
+		t.Error("wrong ip key:", ByIP(r))
+	}
+
+	if ByToken(r) != "ip:10.0.0.1" {
+		t.Error("expected requests without a token to be counted by ip, got", ByToken(r))
+	}
+
+	r.Header.Set("Authorization", "Bearer secret")
+	if key := ByToken(r); key == "ip:10.0.0.1" || len(key) != len("token:")+64 {
+		t.Error("wrong token key:", key)
+	}
+
+	session := scs.New()
+	ctx, err := session.Load(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r = r.WithContext(ctx)
+
+	byUser := ByUser(session)
+	if byUser(r) != "ip:10.0.0.1" {
+		t.Error("expected guests to be counted by ip, got", byUser(r))
+	}
+
+	session.Put(ctx, "userID", 7)
+	if byUser(r) != "user:7" {
+		t.Error("wrong user key:", byUser(r))
+	}
+}
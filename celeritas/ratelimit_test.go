@@ -0,0 +1,58 @@
+package celeritas
+
+import (
+	"github.com/fouched/celeritas/ratelimit"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var rateLimitTests = []struct {
+	name        string
+	path        string
+	accept      string
+	contentType string
+}{
+	{"html", "/users/login", "text/html,application/xhtml+xml", "text/html; charset=utf-8"},
+	{"json-accept", "/users/login", "application/json", "application/json"},
+	{"api", "/api/users", "", "application/json"},
+}
+
+func TestCeleritas_RateLimit(t *testing.T) {
+	for _, e := range rateLimitTests {
+		c := Celeritas{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+		handler := c.RateLimit(ratelimit.Limit{Name: e.name, Requests: 1, Per: time.Minute})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		var w *httptest.ResponseRecorder
+		for i := 0; i < 2; i++ {
+			w = httptest.NewRecorder()
+			r := httptest.NewRequest("GET", e.path, nil)
+			r.Header.Set("Accept", e.accept)
+			handler.ServeHTTP(w, r)
+
+			if i == 0 && (w.Code != http.StatusOK || w.Header().Get("RateLimit-Remaining") != "0") {
+				t.Errorf("%s: first request got %d, remaining %s", e.name, w.Code, w.Header().Get("RateLimit-Remaining"))
+			}
+		}
+
+		if w.Code != http.StatusTooManyRequests {
+			t.Errorf("%s: expected 429, got %d", e.name, w.Code)
+		}
+
+		if w.Header().Get("Retry-After") == "" || w.Header().Get("RateLimit-Limit") != "1" {
+			t.Errorf("%s: missing rate limit headers: %v", e.name, w.Header())
+		}
+
+		if w.Header().Get("Content-Type") != e.contentType {
+			t.Errorf("%s: expected %s, got %s", e.name, e.contentType, w.Header().Get("Content-Type"))
+		}
+
+		if e.contentType == "application/json" && !strings.Contains(w.Body.String(), `"retry_after"`) {
+			t.Errorf("%s: wrong json body: %s", e.name, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package render
+
+import (
+	"github.com/alexedwards/scs/v2"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newGoRenderer returns a renderer for the Go templates in dir, and a request with a session
+func newGoRenderer(t *testing.T, dir string) (*Render, *http.Request) {
+	session := scs.New()
+
+	c := &Render{Renderer: "go", RootPath: dir, Session: session}
+	c.AddFunc("shout", strings.ToUpper)
+	c.AddRequestFunc("user", func(r *http.Request) interface{} {
+		return func() string { return r.URL.Query().Get("user") }
+	})
+
+	r := httptest.NewRequest("GET", "/admin/users?user=ann", nil)
+	ctx, err := session.Load(r.Context(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c, r.WithContext(ctx)
+}
+
+func TestRender_GoPageLayouts(t *testing.T) {
+	c, r := newGoRenderer(t, "./testdata/gopage")
+
+	w := httptest.NewRecorder()
+	err := c.Page(w, r, "admin/users", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<html lang=""><body><nav>MENU</nav><h1>Users of ann</h1></body></html>`
+	if strings.TrimSpace(w.Body.String()) != expected {
+		t.Errorf("expected %s, got %s", expected, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	err = c.Page(w, r, "admin/missing", nil, nil)
+	if err == nil {
+		t.Error("expected an error for a missing page")
+	}
+}
+
+func TestRender_GoPageCache(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "views", "home.page.tmpl")
+	write := func(content string) {
+		if err := os.MkdirAll(filepath.Dir(page), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(page, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, debug := range []bool{false, true} {
+		c, r := newGoRenderer(t, dir)
+		c.Debug = debug
+
+		write("first")
+		w := httptest.NewRecorder()
+		if err := c.GoPage(w, r, "home", nil); err != nil {
+			t.Fatal(err)
+		}
+
+		write("second")
+		w = httptest.NewRecorder()
+		if err := c.GoPage(w, r, "home", nil); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := "first"
+		if debug {
+			expected = "second"
+		}
+		if w.Body.String() != expected {
+			t.Errorf("debug %v: expected %q, got %q", debug, expected, w.Body.String())
+		}
+
+		// new functions are picked up by cached templates
+		c.AddFunc("shout", strings.ToUpper)
+		w = httptest.NewRecorder()
+		if err := c.GoPage(w, r, "home", nil); err != nil {
+			t.Fatal(err)
+		}
+		if w.Body.String() != "second" {
+			t.Errorf("debug %v: expected the cache to be cleared, got %q", debug, w.Body.String())
+		}
+	}
+}
+
+func TestRender_GoPageRequestFuncs(t *testing.T) {
+	c, _ := newGoRenderer(t, "./testdata/gopage")
+
+	// the cached template is shared by requests, each with their own request functions
+	for _, user := range []string{"ann", "bob"} {
+		r := httptest.NewRequest("GET", "/admin/users?user="+user, nil)
+		ctx, _ := c.Session.Load(r.Context(), "")
+
+		w := httptest.NewRecorder()
+		err := c.GoPage(w, r.WithContext(ctx), "admin/users", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(w.Body.String(), "Users of "+user) {
+			t.Errorf("expected the page for %s, got %s", user, w.Body.String())
+		}
+	}
+}
+
+func TestRender_GoPageError(t *testing.T) {
+	dir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(dir, "views"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "views", "broken.page.tmpl"), []byte(`before{{template "missing" .}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, r := newGoRenderer(t, dir)
+
+	w := httptest.NewRecorder()
+	err = c.GoPage(w, r, "broken", nil)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if w.Body.Len() != 0 {
+		t.Errorf("expected nothing to be written, got %q", w.Body.String())
+	}
+}
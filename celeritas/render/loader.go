@@ -0,0 +1,34 @@
+package render
+
+import (
+	"github.com/CloudyKit/jet/v6"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// fsLoader loads Jet templates from an fs.FS, e.g. an embed.FS or os.DirFS
+type fsLoader struct {
+	fsys fs.FS
+}
+
+// NewFSLoader returns a Jet loader that reads templates from fsys, with paths relative to its
+// root, e.g. the views directory
+func NewFSLoader(fsys fs.FS) jet.Loader {
+	return &fsLoader{fsys: fsys}
+}
+
+// name turns a Jet template path, e.g. /layouts/base.jet, into a path in the file system
+func (l *fsLoader) name(templatePath string) string {
+	return strings.TrimPrefix(path.Clean("/"+templatePath), "/")
+}
+
+func (l *fsLoader) Exists(templatePath string) bool {
+	stat, err := fs.Stat(l.fsys, l.name(templatePath))
+	return err == nil && !stat.IsDir()
+}
+
+func (l *fsLoader) Open(templatePath string) (io.ReadCloser, error) {
+	return l.fsys.Open(l.name(templatePath))
+}
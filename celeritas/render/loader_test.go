@@ -0,0 +1,36 @@
+package render
+
+import (
+	"bytes"
+	"github.com/CloudyKit/jet/v6"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNewFSLoader(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.jet": {Data: []byte(`<h1>{{ yield body() }}</h1>`)},
+		"home.jet":         {Data: []byte(`{{ extends "./layouts/base.jet" }}{{ block body() }}embedded{{ end }}`)},
+	}
+
+	set := jet.NewSet(NewFSLoader(fsys))
+
+	tmpl, err := set.GetTemplate("home.jet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err = tmpl.Execute(&out, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out.String() != "<h1>embedded</h1>" {
+		t.Errorf("wrong output %q", out.String())
+	}
+
+	if _, err := set.GetTemplate("missing.jet"); err == nil {
+		t.Error("expected an error for a missing template")
+	}
+}
@@ -0,0 +1,17 @@
+package render
+
+import "context"
+
+type nonceKey struct{}
+
+// WithNonce returns a copy of ctx carrying the Content-Security-Policy nonce of the request
+func WithNonce(ctx context.Context, nonce string) context.Context {
+	return context.WithValue(ctx, nonceKey{}, nonce)
+}
+
+// Nonce returns the Content-Security-Policy nonce of the request, or an empty string if
+// the security headers middleware did not run
+func Nonce(ctx context.Context) string {
+	nonce, _ := ctx.Value(nonceKey{}).(string)
+	return nonce
+}
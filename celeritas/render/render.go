@@ -1,15 +1,26 @@
 package render
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"github.com/CloudyKit/jet/v6"
 	"github.com/alexedwards/scs/v2"
+	"github.com/fouched/celeritas/i18n"
 	"github.com/justinas/nosurf"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"html/template"
+	"io/fs"
 	"log"
 	"net/http"
+	"os"
+	"path"
+	"path/filepath"
 	"strings"
+	"sync"
 )
 
 type Render struct {
@@ -19,7 +30,14 @@ type Render struct {
 	Port       string
 	ServerName string
 	JetViews   *jet.Set
+	Views      fs.FS // the views directory; RootPath/views on disk when nil
+	Debug      bool  // parse Go templates for every page, so that changes show without a restart
 	Session    *scs.SessionManager
+	Funcs      template.FuncMap
+	// RequestFuncs return template functions that depend on the request, e.g. on the signed in user
+	RequestFuncs map[string]func(r *http.Request) interface{}
+
+	cache goTemplates
 }
 
 type TemplateData struct {
@@ -34,9 +52,28 @@ type TemplateData struct {
 	Secure          bool
 	Error           string
 	Flash           string
+	CSPNonce        string // allows inline scripts, e.g. <script nonce="{{.CSPNonce}}">
+	Locale          string // e.g. <html lang="{{.Locale}}">
 }
 
+// tracer records a span for every page rendered, when tracing is enabled
+var tracer = otel.Tracer("github.com/fouched/celeritas/render")
+
 func (c *Render) Page(w http.ResponseWriter, r *http.Request, view string, variables, data interface{}) error {
+	_, span := tracer.Start(r.Context(), "render "+view,
+		trace.WithAttributes(attribute.String("render.engine", c.Renderer), attribute.String("render.view", view)))
+	defer span.End()
+
+	err := c.page(w, r, view, variables, data)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
+}
+
+func (c *Render) page(w http.ResponseWriter, r *http.Request, view string, variables, data interface{}) error {
 	switch strings.ToLower(c.Renderer) {
 	case "go":
 		return c.GoPage(w, r, view, data)
@@ -53,6 +90,8 @@ func (c *Render) defaultData(td *TemplateData, r *http.Request) *TemplateData {
 	td.ServerName = c.ServerName
 	td.CSRFToken = nosurf.Token(r)
 	td.Port = c.Port
+	td.CSPNonce = Nonce(r.Context())
+	td.Locale = i18n.Locale(r.Context())
 
 	if c.Session.Exists(r.Context(), "userID") {
 		td.IsAuthenticated = true
@@ -64,25 +103,128 @@ func (c *Render) defaultData(td *TemplateData, r *http.Request) *TemplateData {
 	return td
 }
 
-// GoPage renders a template using the standard Go template engine
+// GoPage renders a template using the standard Go template engine. The page, views/<view>.page.tmpl,
+// is parsed together with every *.layout.tmpl and *.partial.tmpl in views, so that it can use
+// the templates they define, e.g.
+//
+//	{{template "base" .}}
+//	{{define "content"}}<h1>Home</h1>{{template "nav" .}}{{end}}
+//
+// Parsed templates are cached unless Debug is set
 func (c *Render) GoPage(w http.ResponseWriter, r *http.Request, view string, data interface{}) error {
-	tmpl, err := template.ParseFiles(fmt.Sprintf("%s/views/%s.page.tmpl", c.RootPath, view))
+	tmpl, err := c.goTemplate(view)
+	if err != nil {
+		return err
+	}
+
+	// the cached template is shared, so functions that depend on the request go on a copy
+	tmpl, err = tmpl.Clone()
 	if err != nil {
 		return err
 	}
 
+	funcs := template.FuncMap{}
+	for name, fn := range c.RequestFuncs {
+		funcs[name] = fn(r)
+	}
+	tmpl.Funcs(funcs)
+
 	td := &TemplateData{}
 	if data != nil {
 		td = data.(*TemplateData)
 	}
 	td = c.defaultData(td, r)
 
-	err = tmpl.Execute(w, &td)
+	// render to a buffer, so that a failing template does not send half a page
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, td)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// goTemplates caches parsed pages by view
+type goTemplates struct {
+	mu    sync.RWMutex
+	pages map[string]*template.Template
+}
+
+// goTemplate returns the parsed page for view, from the cache unless Debug is set
+func (c *Render) goTemplate(view string) (*template.Template, error) {
+	if !c.Debug {
+		c.cache.mu.RLock()
+		tmpl, ok := c.cache.pages[view]
+		c.cache.mu.RUnlock()
+		if ok {
+			return tmpl, nil
+		}
+	}
+
+	tmpl, err := c.parseGoTemplate(view)
+	if err != nil {
+		return nil, err
+	}
+
+	if !c.Debug {
+		c.cache.mu.Lock()
+		if c.cache.pages == nil {
+			c.cache.pages = make(map[string]*template.Template)
+		}
+		c.cache.pages[view] = tmpl
+		c.cache.mu.Unlock()
+	}
+
+	return tmpl, nil
+}
+
+// parseGoTemplate parses the page for view with all layouts and partials
+func (c *Render) parseGoTemplate(view string) (*template.Template, error) {
+	views := c.views()
+	page := view + ".page.tmpl"
+
+	files := []string{page}
+	err := fs.WalkDir(views, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && (strings.HasSuffix(name, ".layout.tmpl") || strings.HasSuffix(name, ".partial.tmpl")) {
+			files = append(files, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	funcs := template.FuncMap{}
+	for name, fn := range c.Funcs {
+		funcs[name] = fn
+	}
+	// templates are parsed before there is a request, so request functions are stand-ins
+	// until GoPage replaces them
+	for name := range c.RequestFuncs {
+		funcs[name] = func(...interface{}) interface{} { return nil }
+	}
+
+	return template.New(path.Base(page)).Funcs(funcs).ParseFS(views, files...)
+}
+
+// ClearCache forgets the parsed Go templates, e.g. after adding functions
+func (c *Render) ClearCache() {
+	c.cache.mu.Lock()
+	c.cache.pages = nil
+	c.cache.mu.Unlock()
+}
+
+// views returns the views directory
+func (c *Render) views() fs.FS {
+	if c.Views != nil {
+		return c.Views
+	}
+	return os.DirFS(filepath.Join(c.RootPath, "views"))
 }
 
 // JetPage renders a template using the Jet template engine
@@ -101,6 +243,17 @@ func (c *Render) JetPage(w http.ResponseWriter, r *http.Request, view string, va
 	}
 	td = c.defaultData(td, r)
 
+	// allows inline scripts, e.g. <script nonce="{{ cspNonce }}">
+	if _, ok := vars["cspNonce"]; !ok {
+		vars.Set("cspNonce", td.CSPNonce)
+	}
+
+	for name, fn := range c.RequestFuncs {
+		if _, ok := vars[name]; !ok {
+			vars.Set(name, fn(r))
+		}
+	}
+
 	t, err := c.JetViews.GetTemplate(fmt.Sprintf("%s.jet", view))
 	if err != nil {
 		log.Println(err)
@@ -114,3 +267,31 @@ func (c *Render) JetPage(w http.ResponseWriter, r *http.Request, view string, va
 
 	return nil
 }
+
+// AddRequestFunc makes a function that depends on the request available to both Go and Jet
+// templates under name. fn is called for every page rendered, and returns the function that
+// templates call, e.g.
+//
+//	c.AddRequestFunc("userID", func(r *http.Request) interface{} {
+//		return func() int { return session.GetInt(r.Context(), "userID") }
+//	})
+func (c *Render) AddRequestFunc(name string, fn func(r *http.Request) interface{}) {
+	if c.RequestFuncs == nil {
+		c.RequestFuncs = make(map[string]func(r *http.Request) interface{})
+	}
+	c.RequestFuncs[name] = fn
+	c.ClearCache()
+}
+
+// AddFunc makes a function available to both Go and Jet templates under name
+func (c *Render) AddFunc(name string, fn interface{}) {
+	if c.Funcs == nil {
+		c.Funcs = make(template.FuncMap)
+	}
+	c.Funcs[name] = fn
+	c.ClearCache()
+
+	if c.JetViews != nil {
+		c.JetViews.AddGlobal(name, fn)
+	}
+}
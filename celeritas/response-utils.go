@@ -4,11 +4,9 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"errors"
-	"fmt"
+	"github.com/fouched/celeritas/filesystem"
 	"io"
 	"net/http"
-	"path"
-	"path/filepath"
 )
 
 func (c *Celeritas) ReadJSON(w http.ResponseWriter, r *http.Request, data interface{}) error {
@@ -73,14 +71,10 @@ func (c *Celeritas) WriteXML(w http.ResponseWriter, status int, data interface{}
 	return nil
 }
 
+// DownloadFile streams fileName in the directory pathToFile to the client as an attachment.
+// Use DownloadFromDisk for files on a disk
 func (c *Celeritas) DownloadFile(w http.ResponseWriter, r *http.Request, pathToFile, fileName string) error {
-	fp := path.Join(pathToFile, fileName)
-	fileToServe := filepath.Clean(fp)
-
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
-	http.ServeFile(w, r, fileToServe)
-
-	return nil
+	return c.serveFile(w, r, &filesystem.Local{Root: pathToFile}, fileName, "attachment")
 }
 
 func (c *Celeritas) ErrorStatus(w http.ResponseWriter, status int) {
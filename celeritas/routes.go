@@ -16,11 +16,18 @@ func (c *Celeritas) routes() http.Handler {
 func addMiddleware(mux *chi.Mux, c *Celeritas) {
 	mux.Use(middleware.RequestID)
 	mux.Use(middleware.RealIP)
+	mux.Use(c.Tracing.Middleware) // spans for requests, named by route pattern, when tracing is enabled
+	mux.Use(c.RequestLogger)
 	mux.Use(middleware.Recoverer)
-	//if c.Debug {
-	//	mux.Use(middleware.Logger)
-	//}
+	mux.Use(c.HSTS)
+	mux.Use(c.HealthProbes) // liveness and readiness probes, as middleware so applications can still call Use
+	mux.Use(c.HTTPMetrics)  // the metrics endpoint, and request counts and latency
+	mux.Use(c.corsPaths)    // cross-origin requests to the API, configured by CORS_*
+	mux.Use(c.SecurityHeaders)
+	mux.Use(c.MaintenanceMode) // 503 while the application is down, see `celeritas down`
+	mux.Use(c.StorageURLs)     // temporary URLs of local disks
 
 	mux.Use(c.SessionLoad)
+	mux.Use(c.Localize) // before NoSurf, so a locale prefix is removed before its exempt paths are matched
 	mux.Use(c.NoSurf)
 }
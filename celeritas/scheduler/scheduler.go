@@ -0,0 +1,203 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fouched/celeritas/cache"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Scheduler runs tasks on a schedule. A task never overlaps with a previous run of itself; a
+// run that is due while the previous one is still busy is skipped
+type Scheduler struct {
+	Logger *slog.Logger
+	Locker cache.Locker // needed by tasks that run on one server
+
+	mu      sync.Mutex
+	tasks   []*Task
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+}
+
+// New creates a scheduler that logs every run to logger
+func New(logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		Logger: logger,
+	}
+}
+
+// Call adds a task that calls fn. The context passed to fn is cancelled when the scheduler
+// stops. Tasks must be added before the scheduler starts
+func (s *Scheduler) Call(name string, fn func(ctx context.Context) error) *Task {
+	t := &Task{
+		Name: name,
+		fn:   fn,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tasks = append(s.tasks, t)
+
+	return t
+}
+
+// Tasks returns the tasks that were added to the scheduler
+func (s *Scheduler) Tasks() []*Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*Task(nil), s.tasks...)
+}
+
+// Start checks that every task has a valid schedule, and starts running them in the background
+func (s *Scheduler) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return nil
+	}
+
+	var errs []error
+	for _, t := range s.tasks {
+		if err := t.validate(); err != nil {
+			errs = append(errs, err)
+		} else if t.oneServer && s.Locker == nil {
+			errs = append(errs, fmt.Errorf("task %s runs on one server, which requires a redis or badger cache", t.Name))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.started = true
+
+	for _, t := range s.tasks {
+		s.wg.Add(1)
+		go s.loop(ctx, t)
+	}
+
+	return nil
+}
+
+// Stop stops scheduling tasks, cancels the context of the running tasks, and waits for
+// them to return or for ctx to expire, whichever comes first
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.started {
+		s.mu.Unlock()
+		return nil
+	}
+	s.started = false
+	s.cancel()
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("scheduler: tasks still running: %w", ctx.Err())
+	}
+}
+
+// loop waits until the task is due, and runs it, until the scheduler stops
+func (s *Scheduler) loop(ctx context.Context, t *Task) {
+	defer s.wg.Done()
+
+	for {
+		next := t.Next(time.Now())
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.wg.Add(1)
+			go s.run(ctx, t, next)
+		}
+	}
+}
+
+// run runs the task once, unless it is still running, or another server claimed the run
+func (s *Scheduler) run(ctx context.Context, t *Task, due time.Time) {
+	defer s.wg.Done()
+	log := s.logger().With("task", t.Name)
+
+	if !t.running.CompareAndSwap(false, true) {
+		log.Warn("scheduled task skipped, previous run still in progress")
+		return
+	}
+	defer t.running.Store(false)
+
+	if t.oneServer {
+		// claim this particular run, so servers that are a little late don't run it again
+		_, ok, err := s.Locker.Lock(fmt.Sprintf("schedule:%s:%d", t.Name, due.Unix()), t.lockTTL)
+		if err != nil {
+			log.Error("scheduled task skipped, could not acquire lock", "error", err)
+			return
+		} else if !ok {
+			log.Debug("scheduled task skipped, run claimed by another server")
+			return
+		}
+
+		// and hold the task while running, so it doesn't overlap with a run on another server
+		token, ok, err := s.Locker.Lock("schedule:"+t.Name, t.lockTTL)
+		if err != nil {
+			log.Error("scheduled task skipped, could not acquire lock", "error", err)
+			return
+		} else if !ok {
+			log.Warn("scheduled task skipped, previous run still in progress on another server")
+			return
+		}
+
+		defer func() {
+			if err := s.Locker.Unlock("schedule:"+t.Name, token); err != nil {
+				log.Error("could not release scheduled task lock", "error", err)
+			}
+		}()
+	}
+
+	log.Info("scheduled task started")
+	start := time.Now()
+
+	err := call(ctx, t.fn)
+	if err != nil {
+		log.Error("scheduled task failed", "duration", time.Since(start), "error", err)
+		return
+	}
+
+	log.Info("scheduled task finished", "duration", time.Since(start))
+}
+
+// call calls fn, turning a panic into an error so a misbehaving task can't take down the application
+func call(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return fn(ctx)
+}
+
+func (s *Scheduler) logger() *slog.Logger {
+	if s.Logger == nil {
+		return slog.Default()
+	}
+	return s.Logger
+}
@@ -0,0 +1,170 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduler_Run(t *testing.T) {
+	var runs atomic.Int32
+
+	s := New(testLogger)
+	s.Call("count", func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}).Every(20 * time.Millisecond)
+
+	err := s.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(110 * time.Millisecond)
+
+	err = s.Stop(context.Background())
+	if err != nil {
+		t.Error(err)
+	}
+
+	if runs.Load() < 2 {
+		t.Error("expected the task to run at least twice, but it ran", runs.Load())
+	}
+}
+
+func TestScheduler_NoOverlap(t *testing.T) {
+	var running, overlaps atomic.Int32
+
+	s := New(testLogger)
+	s.Call("slow", func(ctx context.Context) error {
+		if running.Add(1) > 1 {
+			overlaps.Add(1)
+		}
+		defer running.Add(-1)
+
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}).Every(10 * time.Millisecond)
+
+	_ = s.Start()
+	time.Sleep(120 * time.Millisecond)
+	_ = s.Stop(context.Background())
+
+	if overlaps.Load() > 0 {
+		t.Error("task overlapped with itself", overlaps.Load(), "time(s)")
+	}
+}
+
+func TestScheduler_OnOneServer(t *testing.T) {
+	var runs atomic.Int32
+	locker := &memoryLocker{}
+	due := time.Now().Truncate(time.Minute)
+
+	// two servers sharing a cache, both running the same run of the same task
+	for i := 0; i < 2; i++ {
+		s := New(testLogger)
+		s.Locker = locker
+		task := s.Call("report", func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		}).EveryMinute().OnOneServer()
+
+		s.wg.Add(1)
+		s.run(context.Background(), task, due)
+	}
+
+	if runs.Load() != 1 {
+		t.Error("expected the task to run on exactly one server, but it ran", runs.Load(), "times")
+	}
+
+	// the task lock is released after the run, so the next run can go ahead
+	s := New(testLogger)
+	s.Locker = locker
+	task := s.Call("report", func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}).EveryMinute().OnOneServer()
+
+	s.wg.Add(1)
+	s.run(context.Background(), task, due.Add(time.Minute))
+
+	if runs.Load() != 2 {
+		t.Error("expected the next run to go ahead")
+	}
+}
+
+func TestScheduler_OnOneServer_RequiresLocker(t *testing.T) {
+	s := New(testLogger)
+	s.Call("report", noop).Hourly().OnOneServer()
+
+	if err := s.Start(); err == nil {
+		t.Error("expected an error starting a task on one server without a locker")
+	}
+}
+
+func TestScheduler_Panic(t *testing.T) {
+	s := New(testLogger)
+	task := s.Call("panics", func(ctx context.Context) error {
+		panic("boom")
+	}).Hourly()
+
+	s.wg.Add(1)
+	s.run(context.Background(), task, time.Now())
+
+	if task.Running() {
+		t.Error("task still marked as running after it panicked")
+	}
+
+	err := call(context.Background(), func(ctx context.Context) error { panic("boom") })
+	if err == nil || err.Error() != "panic: boom" {
+		t.Error("expected panic to be returned as an error, got", err)
+	}
+}
+
+func TestScheduler_Stop(t *testing.T) {
+	started := make(chan struct{})
+	var cancelled atomic.Bool
+
+	s := New(testLogger)
+	s.Call("wait", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		cancelled.Store(true)
+		return ctx.Err()
+	}).Every(10 * time.Millisecond)
+
+	_ = s.Start()
+	<-started
+
+	err := s.Stop(context.Background())
+	if err != nil {
+		t.Error(err)
+	}
+
+	if !cancelled.Load() {
+		t.Error("Stop returned before the running task was cancelled")
+	}
+
+	// a task that ignores cancellation makes Stop give up when its context expires
+	s = New(testLogger)
+	block := make(chan struct{})
+	defer close(block)
+
+	s.Call("stuck", func(ctx context.Context) error {
+		<-block
+		return nil
+	}).Every(10 * time.Millisecond)
+
+	_ = s.Start()
+	time.Sleep(30 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err = s.Stop(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected deadline exceeded, got", err)
+	}
+}
@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// memoryLocker is a cache.Locker that is shared by schedulers in the same test, standing in
+// for a cache shared by several servers
+type memoryLocker struct {
+	mu    sync.Mutex
+	locks map[string]string
+}
+
+func (m *memoryLocker) Lock(key string, ttl time.Duration) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.locks[key]; ok {
+		return "", false, nil
+	}
+
+	if m.locks == nil {
+		m.locks = make(map[string]string)
+	}
+	m.locks[key] = key
+	return key, true, nil
+}
+
+func (m *memoryLocker) Unlock(key, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.locks[key] == token {
+		delete(m.locks, key)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"github.com/robfig/cron/v3"
+	"sync/atomic"
+	"time"
+)
+
+// defaultLockTTL is how long a task that runs on one server holds its lock, unless changed with ExpireLockAfter
+const defaultLockTTL = time.Hour
+
+// Task is a function that runs on a schedule. Tasks are created with Scheduler.Call, and
+// scheduled with either Cron or one of the interval methods, e.g.
+//
+//	s.Call("tokens:cleanup", cleanup).DailyAt("03:00").OnOneServer()
+type Task struct {
+	Name string
+
+	fn        func(ctx context.Context) error
+	spec      string
+	schedule  cron.Schedule
+	err       error
+	oneServer bool
+	lockTTL   time.Duration
+	running   atomic.Bool
+}
+
+// every runs at multiples of an interval, rather than an interval after the scheduler started,
+// so that every replica agrees on when a task is due
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	d := time.Duration(e)
+	return t.Truncate(d).Add(d)
+}
+
+// Cron schedules the task with a standard five field cron expression, e.g. "*/5 * * * *", or
+// a descriptor such as @hourly, @daily, @weekly or @monthly. Prefix the expression with
+// CRON_TZ=<zone> to evaluate it in a time zone other than the local one
+func (t *Task) Cron(expr string) *Task {
+	schedule, err := cron.ParseStandard(expr)
+	if err != nil {
+		t.err = fmt.Errorf("task %s: invalid cron expression %q: %w", t.Name, expr, err)
+		return t
+	}
+
+	t.spec = expr
+	t.schedule = schedule
+	t.err = nil
+
+	return t
+}
+
+// Every schedules the task to run every d, at multiples of d since the zero time
+func (t *Task) Every(d time.Duration) *Task {
+	if d <= 0 {
+		t.err = fmt.Errorf("task %s: interval must be positive, got %s", t.Name, d)
+		return t
+	}
+
+	t.spec = "@every " + d.String()
+	t.schedule = every(d)
+	t.err = nil
+
+	return t
+}
+
+// EveryMinute schedules the task to run at the start of every minute
+func (t *Task) EveryMinute() *Task {
+	return t.Cron("* * * * *")
+}
+
+// EveryFiveMinutes schedules the task to run every five minutes
+func (t *Task) EveryFiveMinutes() *Task {
+	return t.Cron("*/5 * * * *")
+}
+
+// EveryFifteenMinutes schedules the task to run every fifteen minutes
+func (t *Task) EveryFifteenMinutes() *Task {
+	return t.Cron("*/15 * * * *")
+}
+
+// EveryThirtyMinutes schedules the task to run every thirty minutes
+func (t *Task) EveryThirtyMinutes() *Task {
+	return t.Cron("*/30 * * * *")
+}
+
+// Hourly schedules the task to run at the start of every hour
+func (t *Task) Hourly() *Task {
+	return t.Cron("0 * * * *")
+}
+
+// Daily schedules the task to run at midnight
+func (t *Task) Daily() *Task {
+	return t.Cron("0 0 * * *")
+}
+
+// DailyAt schedules the task to run every day at the given time, in 24-hour HH:MM format
+func (t *Task) DailyAt(at string) *Task {
+	clock, err := time.Parse("15:04", at)
+	if err != nil {
+		t.err = fmt.Errorf("task %s: invalid time of day %q, expected HH:MM", t.Name, at)
+		return t
+	}
+
+	return t.Cron(fmt.Sprintf("%d %d * * *", clock.Minute(), clock.Hour()))
+}
+
+// Weekly schedules the task to run at midnight on Sundays
+func (t *Task) Weekly() *Task {
+	return t.Cron("0 0 * * 0")
+}
+
+// Monthly schedules the task to run at midnight on the first day of the month
+func (t *Task) Monthly() *Task {
+	return t.Cron("0 0 1 * *")
+}
+
+// OnOneServer makes sure that when the application runs on more than one server, only one
+// of them runs each scheduled run of the task, and runs do not overlap across servers. It
+// requires the scheduler to have a Locker, i.e. a redis or badger cache
+func (t *Task) OnOneServer() *Task {
+	t.oneServer = true
+	if t.lockTTL == 0 {
+		t.lockTTL = defaultLockTTL
+	}
+	return t
+}
+
+// ExpireLockAfter sets how long a task that runs on one server holds its lock. It should be
+// longer than the task ever takes, since the lock is the only thing that stops another server
+// from starting the task while it is still running
+func (t *Task) ExpireLockAfter(d time.Duration) *Task {
+	t.lockTTL = d
+	return t
+}
+
+// Spec returns the schedule of the task, as a cron expression or an @every interval
+func (t *Task) Spec() string {
+	return t.spec
+}
+
+// Next returns the first time after after that the task is due, or the zero time if the task is not scheduled
+func (t *Task) Next(after time.Time) time.Time {
+	if t.schedule == nil {
+		return time.Time{}
+	}
+	return t.schedule.Next(after)
+}
+
+// RunsOnOneServer reports whether OnOneServer was called for the task
+func (t *Task) RunsOnOneServer() bool {
+	return t.oneServer
+}
+
+// Running reports whether the task is running right now in this process
+func (t *Task) Running() bool {
+	return t.running.Load()
+}
+
+// validate reports an invalid or missing schedule
+func (t *Task) validate() error {
+	if t.err != nil {
+		return t.err
+	}
+
+	if t.schedule == nil {
+		return fmt.Errorf("task %s has no schedule", t.Name)
+	}
+
+	return nil
+}
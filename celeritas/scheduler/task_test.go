@@ -0,0 +1,68 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+var noop = func(ctx context.Context) error { return nil }
+
+var scheduleTests = []struct {
+	name     string
+	schedule func(t *Task) *Task
+	spec     string
+	next     string
+}{
+	{"cron", func(t *Task) *Task { return t.Cron("*/5 * * * *") }, "*/5 * * * *", "2025-01-15T10:25:00Z"},
+	{"every", func(t *Task) *Task { return t.Every(12 * time.Hour) }, "@every 12h0m0s", "2025-01-15T12:00:00Z"},
+	{"every-minute", func(t *Task) *Task { return t.EveryMinute() }, "* * * * *", "2025-01-15T10:24:00Z"},
+	{"hourly", func(t *Task) *Task { return t.Hourly() }, "0 * * * *", "2025-01-15T11:00:00Z"},
+	{"daily", func(t *Task) *Task { return t.Daily() }, "0 0 * * *", "2025-01-16T00:00:00Z"},
+	{"daily-at", func(t *Task) *Task { return t.DailyAt("03:30") }, "30 3 * * *", "2025-01-16T03:30:00Z"},
+	{"weekly", func(t *Task) *Task { return t.Weekly() }, "0 0 * * 0", "2025-01-19T00:00:00Z"},
+	{"monthly", func(t *Task) *Task { return t.Monthly() }, "0 0 1 * *", "2025-02-01T00:00:00Z"},
+}
+
+func TestTask_Schedules(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 23, 45, 0, time.UTC)
+
+	for _, e := range scheduleTests {
+		task := e.schedule(&Task{Name: e.name})
+
+		if err := task.validate(); err != nil {
+			t.Errorf("%s: unexpected error: %s", e.name, err)
+			continue
+		}
+
+		if task.Spec() != e.spec {
+			t.Errorf("%s: expected spec %q but got %q", e.name, e.spec, task.Spec())
+		}
+
+		if next := task.Next(now).Format(time.RFC3339); next != e.next {
+			t.Errorf("%s: expected next run at %s but got %s", e.name, e.next, next)
+		}
+	}
+}
+
+func TestTask_InvalidSchedules(t *testing.T) {
+	var tests = []struct {
+		name     string
+		schedule func(t *Task) *Task
+	}{
+		{"bad-cron", func(t *Task) *Task { return t.Cron("every tuesday") }},
+		{"bad-time", func(t *Task) *Task { return t.DailyAt("25:00") }},
+		{"bad-interval", func(t *Task) *Task { return t.Every(0) }},
+		{"no-schedule", func(t *Task) *Task { return t }},
+	}
+
+	for _, e := range tests {
+		s := New(testLogger)
+		e.schedule(s.Call(e.name, noop))
+
+		if err := s.Start(); err == nil {
+			t.Errorf("%s: expected an error starting the scheduler", e.name)
+			_ = s.Stop(context.Background())
+		}
+	}
+}
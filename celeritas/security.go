@@ -0,0 +1,115 @@
+package celeritas
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"github.com/fouched/celeritas/render"
+	"net/http"
+	"strings"
+)
+
+// defaultCSP only allows resources from the application itself, and inline scripts carrying
+// the nonce of the request
+const defaultCSP = "default-src 'self'; script-src 'self' 'nonce-{nonce}'; style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; connect-src 'self'; object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors 'self'"
+
+// applyDefaults fills in the headers that are not configured
+func (cfg *SecurityConfig) applyDefaults() {
+	if cfg.CSP == "" {
+		cfg.CSP = defaultCSP
+	}
+
+	if cfg.FrameOptions == "" {
+		cfg.FrameOptions = "SAMEORIGIN"
+	}
+
+	if cfg.ReferrerPolicy == "" {
+		cfg.ReferrerPolicy = "strict-origin-when-cross-origin"
+	}
+
+	if cfg.PermissionsPolicy == "" {
+		cfg.PermissionsPolicy = "camera=(), microphone=(), geolocation=(), payment=()"
+	}
+}
+
+// SecurityHeaders sets the configured Content-Security-Policy, X-Frame-Options,
+// Referrer-Policy and Permissions-Policy headers, and X-Content-Type-Options: nosniff. The
+// CSP nonce of the request is available to views as .CSPNonce, or cspNonce in Jet
+func (c *Celeritas) SecurityHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce, err := cspNonce()
+		if err != nil {
+			c.Error500(w)
+			return
+		}
+
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		c.config.Security.apply(w.Header(), nonce)
+
+		next.ServeHTTP(w, r.WithContext(render.WithNonce(r.Context(), nonce)))
+	})
+}
+
+// OverrideSecurityHeaders returns middleware that replaces the security headers set by
+// SecurityHeaders for some routes. Empty fields keep the configured header, e.g.
+//
+//	a.App.Routes.With(a.App.OverrideSecurityHeaders(celeritas.SecurityConfig{FrameOptions: "-"})).
+//		Get("/embed", a.Handlers.Embed)
+func (c *Celeritas) OverrideSecurityHeaders(overrides SecurityConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			nonce := render.Nonce(r.Context())
+			if nonce == "" {
+				var err error
+				nonce, err = cspNonce()
+				if err != nil {
+					c.Error500(w)
+					return
+				}
+				r = r.WithContext(render.WithNonce(r.Context(), nonce))
+			}
+
+			if overrides.CSP != "" {
+				// the override may switch between enforcing and reporting
+				w.Header().Del("Content-Security-Policy")
+				w.Header().Del("Content-Security-Policy-Report-Only")
+			}
+			overrides.apply(w.Header(), nonce)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// apply sets the headers in cfg that are not empty, and removes those set to -
+func (cfg SecurityConfig) apply(h http.Header, nonce string) {
+	cspHeader := "Content-Security-Policy"
+	if cfg.CSPReportOnly {
+		cspHeader = "Content-Security-Policy-Report-Only"
+	}
+
+	set := func(name, value string) {
+		switch value {
+		case "":
+		case "-":
+			h.Del(name)
+		default:
+			h.Set(name, value)
+		}
+	}
+
+	set(cspHeader, strings.ReplaceAll(cfg.CSP, "{nonce}", nonce))
+	set("X-Frame-Options", cfg.FrameOptions)
+	set("Referrer-Policy", cfg.ReferrerPolicy)
+	set("Permissions-Policy", cfg.PermissionsPolicy)
+}
+
+// cspNonce returns a random, base64 encoded nonce
+func cspNonce() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
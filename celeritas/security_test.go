@@ -0,0 +1,77 @@
+package celeritas
+
+import (
+	"github.com/fouched/celeritas/render"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCeleritas_SecurityHeaders(t *testing.T) {
+	cfg, _ := ReadConfig(map[string]string{})
+	c := Celeritas{config: cfg}
+
+	var nonce string
+	handler := c.SecurityHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce = render.Nonce(r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if nonce == "" {
+		t.Fatal("no nonce in the request context")
+	}
+
+	if csp := w.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "'nonce-"+nonce+"'") {
+		t.Error("nonce missing from the CSP:", csp)
+	}
+
+	expected := map[string]string{
+		"X-Content-Type-Options": "nosniff",
+		"X-Frame-Options":        "SAMEORIGIN",
+		"Referrer-Policy":        "strict-origin-when-cross-origin",
+	}
+	for k, v := range expected {
+		if got := w.Header().Get(k); got != v {
+			t.Errorf("expected %s %q, got %q", k, v, got)
+		}
+	}
+
+	// every request gets its own nonce
+	first := nonce
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if nonce == first {
+		t.Error("nonce reused between requests")
+	}
+}
+
+var securityOverrideTests = []struct {
+	name      string
+	overrides SecurityConfig
+	header    string
+	expected  string
+}{
+	{"keep", SecurityConfig{}, "X-Frame-Options", "SAMEORIGIN"},
+	{"replace", SecurityConfig{FrameOptions: "DENY"}, "X-Frame-Options", "DENY"},
+	{"remove", SecurityConfig{FrameOptions: "-"}, "X-Frame-Options", ""},
+	{"csp", SecurityConfig{CSP: "default-src 'none'"}, "Content-Security-Policy", "default-src 'none'"},
+	{"csp-report-only", SecurityConfig{CSP: "default-src 'none'", CSPReportOnly: true}, "Content-Security-Policy", ""},
+}
+
+func TestCeleritas_OverrideSecurityHeaders(t *testing.T) {
+	cfg, _ := ReadConfig(map[string]string{})
+	c := Celeritas{config: cfg}
+
+	for _, e := range securityOverrideTests {
+		handler := c.SecurityHeaders(c.OverrideSecurityHeaders(e.overrides)(http.NotFoundHandler()))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+		if got := w.Header().Get(e.header); got != e.expected {
+			t.Errorf("%s: expected %s %q, got %q", e.name, e.header, e.expected, got)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"github.com/alexedwards/scs/v2"
+	"github.com/fouched/celeritas/events"
+	"time"
+)
+
+// Saved is published when session data is written to the store, i.e. at the end of every
+// request that changed the session, and when the session token is renewed
+type Saved struct {
+	Expiry time.Time
+}
+
+func (Saved) Name() string { return "session.saved" }
+
+// Destroyed is published when a session is removed from the store, i.e. when it is destroyed,
+// and when its token is renewed
+type Destroyed struct{}
+
+func (Destroyed) Name() string { return "session.destroyed" }
+
+// eventStore publishes an event whenever the store it wraps saves or deletes a session
+type eventStore struct {
+	store  scs.Store
+	events *events.Bus
+}
+
+func (s eventStore) Find(token string) ([]byte, bool, error) {
+	return s.store.Find(token)
+}
+
+func (s eventStore) Commit(token string, b []byte, expiry time.Time) error {
+	err := s.store.Commit(token, b, expiry)
+	if err == nil {
+		s.events.Publish(context.Background(), Saved{Expiry: expiry})
+	}
+
+	return err
+}
+
+func (s eventStore) Delete(token string) error {
+	err := s.store.Delete(token)
+	if err == nil {
+		s.events.Publish(context.Background(), Destroyed{})
+	}
+
+	return err
+}
+
+// All lets scs iterate over the sessions, if the wrapped store supports it
+func (s eventStore) All() (map[string][]byte, error) {
+	if store, ok := s.store.(scs.IterableStore); ok {
+		return store.All()
+	}
+
+	return nil, errors.New("session store does not support iteration")
+}
@@ -5,7 +5,9 @@ import (
 	"github.com/alexedwards/scs/mysqlstore"
 	"github.com/alexedwards/scs/postgresstore"
 	"github.com/alexedwards/scs/redisstore"
+	"github.com/alexedwards/scs/sqlite3store"
 	"github.com/alexedwards/scs/v2"
+	"github.com/fouched/celeritas/events"
 	"github.com/gomodule/redigo/redis"
 	"net/http"
 	"strconv"
@@ -22,6 +24,7 @@ type Session struct {
 	SessionType    string
 	DBPool         *sql.DB
 	RedisPool      *redis.Pool
+	Events         *events.Bus
 }
 
 func (s *Session) InitSession() *scs.SessionManager {
@@ -57,9 +60,15 @@ func (s *Session) InitSession() *scs.SessionManager {
 	case "postgres", "postgresql":
 		// we are using postgresstore, but c.DBPool contains the optimized pqx driver connection
 		session.Store = postgresstore.New(s.DBPool)
+	case "sqlite":
+		session.Store = sqlite3store.New(s.DBPool)
 	default:
 		// cookie
 	}
 
+	if s.Events != nil {
+		session.Store = eventStore{store: session.Store, events: s.Events}
+	}
+
 	return session
 }
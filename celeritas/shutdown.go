@@ -0,0 +1,122 @@
+package celeritas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ShutdownHook is a function that is called when the application shuts down
+type ShutdownHook func(ctx context.Context) error
+
+// OnShutdown registers a hook that is called during a graceful shutdown. Hooks run in the
+// order they were registered, after the web server stopped accepting requests, but before
+// mail is drained and the database and cache connections are closed
+func (c *Celeritas) OnShutdown(hook ShutdownHook) {
+	c.shutdownHooks = append(c.shutdownHooks, hook)
+}
+
+// Shutdown gracefully stops the application. In-flight requests are given until the context
+// expires to complete, after which scheduled tasks and job workers are stopped, shutdown hooks
+// and providers run, queued mail is sent, queued events are handled, all connection pools are closed, the remaining
+// trace spans are exported and the log file is closed, in that order. It is safe to call more than once; only the first call has any effect
+func (c *Celeritas) Shutdown(ctx context.Context) error {
+	c.shutdownOnce.Do(func() {
+		c.shutdownErr = c.shutdown(ctx)
+	})
+
+	return c.shutdownErr
+}
+
+func (c *Celeritas) shutdown(ctx context.Context) error {
+	var errs []error
+
+	// disconnect WebSocket and SSE clients, which would otherwise keep the server from shutting down
+	if c.Broadcast != nil {
+		c.Broadcast.Close()
+	}
+
+	if c.server != nil {
+		if err := c.server.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if c.redirectServer != nil {
+		if err := c.redirectServer.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if c.Scheduler != nil {
+		if err := c.Scheduler.Stop(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if c.stopWorkers != nil {
+		// let the jobs in progress finish
+		c.stopWorkers()
+		select {
+		case <-c.workersDone:
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("jobs still running: %w", ctx.Err()))
+		}
+	}
+
+	for _, hook := range c.shutdownHooks {
+		if err := hook(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	errs = append(errs, c.shutdownProviders(ctx)...)
+
+	if c.Mail.Jobs != nil {
+		if err := c.Mail.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// let asynchronous listeners finish, including those for the mail sent above
+	if err := c.Events.Close(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.DB.Pool != nil {
+		if err := c.DB.Pool.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if redisPool != nil {
+		if err := redisPool.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if badgerConn != nil {
+		if err := badgerConn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if badgerJobsConn != nil {
+		if err := badgerJobsConn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// send the remaining spans, including those recorded while shutting down
+	if err := c.Tracing.Shutdown(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.logCloser != nil {
+		if err := c.logCloser.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
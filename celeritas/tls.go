@@ -0,0 +1,58 @@
+package celeritas
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// tlsEnabled reports whether the web server should serve HTTPS
+func (c *Celeritas) tlsEnabled() bool {
+	return c.config.TLS.CertFile != "" && c.config.TLS.KeyFile != ""
+}
+
+func (c *Celeritas) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+}
+
+// newRedirectServer creates the plain HTTP server that redirects every request to HTTPS
+func (c *Celeritas) newRedirectServer() *http.Server {
+	return &http.Server{
+		Addr:         fmt.Sprintf(":%s", c.config.TLS.RedirectPort),
+		ErrorLog:     c.ErrorLog,
+		Handler:      http.HandlerFunc(c.redirectToHTTPS),
+		IdleTimeout:  30 * time.Second,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 5 * time.Second,
+	}
+}
+
+func (c *Celeritas) redirectToHTTPS(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	if c.config.Port != "443" {
+		host = net.JoinHostPort(host, c.config.Port)
+	}
+
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+}
+
+// HSTS sets the Strict-Transport-Security header on responses to HTTPS requests, so
+// browsers only use HTTPS for the site in future
+func (c *Celeritas) HSTS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		maxAge := int(c.config.TLS.HSTSMaxAge.Seconds())
+		if maxAge > 0 && (r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https") {
+			w.Header().Set("Strict-Transport-Security", fmt.Sprintf("max-age=%d; includeSubDomains", maxAge))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
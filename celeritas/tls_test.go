@@ -0,0 +1,58 @@
+package celeritas
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var redirectTests = []struct {
+	name     string
+	port     string
+	url      string
+	expected string
+}{
+	{"custom-port", "4443", "http://localhost:4080/users/login?next=%2F", "https://localhost:4443/users/login?next=%2F"},
+	{"default-port", "443", "http://example.com/", "https://example.com/"},
+}
+
+func TestCeleritas_redirectToHTTPS(t *testing.T) {
+	for _, e := range redirectTests {
+		c := Celeritas{config: Config{Port: e.port}}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", e.url, nil)
+		c.redirectToHTTPS(w, r)
+
+		if w.Code != http.StatusMovedPermanently {
+			t.Errorf("%s: wrong status code %d", e.name, w.Code)
+		}
+
+		if w.Header().Get("Location") != e.expected {
+			t.Errorf("%s: expected redirect to %s but got %s", e.name, e.expected, w.Header().Get("Location"))
+		}
+	}
+}
+
+func TestCeleritas_HSTS(t *testing.T) {
+	c := Celeritas{config: Config{TLS: TLSConfig{HSTSMaxAge: time.Hour}}}
+	handler := c.HSTS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, r)
+
+	if w.Header().Get("Strict-Transport-Security") != "" {
+		t.Error("HSTS header should not be sent over plain http")
+	}
+
+	w = httptest.NewRecorder()
+	r.TLS = &tls.ConnectionState{}
+	handler.ServeHTTP(w, r)
+
+	if w.Header().Get("Strict-Transport-Security") != "max-age=3600; includeSubDomains" {
+		t.Error("wrong HSTS header:", w.Header().Get("Strict-Transport-Security"))
+	}
+}
@@ -0,0 +1,22 @@
+package celeritas
+
+import (
+	"github.com/fouched/celeritas/tracing"
+)
+
+// createTracing sets up OpenTelemetry tracing with the exporter in TRACING_EXPORTER. It returns
+// nil when tracing is disabled, in which case the instrumented packages record nothing
+func (c *Celeritas) createTracing() (*tracing.Tracing, error) {
+	if c.config.Tracing.Exporter == "" {
+		return nil, nil
+	}
+
+	return tracing.New(tracing.Options{
+		Exporter:    c.config.Tracing.Exporter,
+		File:        c.config.Tracing.File,
+		Endpoint:    c.config.Tracing.Endpoint,
+		ServiceName: c.config.AppName,
+		Version:     c.Version,
+		SampleRatio: *c.config.Tracing.SampleRatio,
+	})
+}
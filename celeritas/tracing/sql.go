@@ -0,0 +1,44 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"github.com/XSAM/otelsql"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OpenDB opens a connection pool like sql.Open, which records a span for every query made
+// with a context that belongs to a trace, e.g. db.QueryContext(r.Context(), ...). Queries
+// made outside a trace, such as job workers polling for jobs, are not recorded, so they do
+// not flood the exporter with single span traces. system is the database type, e.g. postgres
+func OpenDB(driverName, dsn, system string) (*sql.DB, error) {
+	return otelsql.Open(driverName, dsn,
+		otelsql.WithAttributes(dbSystem(system)),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitConnResetSession: true,
+			OmitRows:             true,
+			SpanFilter: func(ctx context.Context, method otelsql.Method, query string, args []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}),
+	)
+}
+
+func dbSystem(system string) attribute.KeyValue {
+	switch system {
+	case "postgres", "postgresql":
+		return semconv.DBSystemPostgreSQL
+	case "mysql":
+		return semconv.DBSystemMySQL
+	case "mariadb":
+		return semconv.DBSystemMariaDB
+	case "sqlite":
+		return semconv.DBSystemSqlite
+	default:
+		return semconv.DBSystemKey.String(system)
+	}
+}
@@ -0,0 +1,175 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// instrumentation is the name of the tracer that records the framework's spans
+const instrumentation = "github.com/fouched/celeritas"
+
+type Options struct {
+	Exporter    string  // stdout, file or otlp
+	File        string  // where the file exporter writes spans, one JSON object per line
+	Endpoint    string  // OTLP/HTTP collector URL; if empty, OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4318 is used
+	ServiceName string  // the name traces are reported under, e.g. the application name
+	Version     string  // the version of the service
+	SampleRatio float64 // fraction of new traces recorded; traces started by a caller follow its decision
+}
+
+// Tracing records spans and sends them to the configured exporter
+type Tracing struct {
+	Provider *sdktrace.TracerProvider
+
+	closer io.Closer // the file spans are written to, if any
+}
+
+// New creates a tracer provider with the exporter in the options, and installs it, together
+// with W3C trace context and baggage propagation, as the global provider. Packages that are
+// instrumented with otel.Tracer, e.g. render, mailer and cache, report to it from then on
+func New(o Options) (*Tracing, error) {
+	exporter, closer, err := newExporter(o)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(context.Background(),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(o.ServiceName), semconv.ServiceVersion(o.Version)),
+	)
+	if err != nil {
+		return nil, errors.Join(err, closer.Close())
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(o.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return &Tracing{Provider: provider, closer: closer}, nil
+}
+
+func newExporter(o Options) (sdktrace.SpanExporter, io.Closer, error) {
+	switch strings.ToLower(o.Exporter) {
+	case "stdout":
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		return exporter, nopCloser{}, err
+	case "file":
+		err := os.MkdirAll(filepath.Dir(o.File), 0755)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		f, err := os.OpenFile(o.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			return nil, nil, errors.Join(err, f.Close())
+		}
+		return exporter, f, nil
+	case "otlp":
+		var opts []otlptracehttp.Option
+		if o.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(o.Endpoint))
+		}
+
+		exporter, err := otlptracehttp.New(context.Background(), opts...)
+		return exporter, nopCloser{}, err
+	default:
+		return nil, nil, fmt.Errorf("unknown trace exporter %q, only stdout, file or otlp accepted", o.Exporter)
+	}
+}
+
+// Shutdown sends the spans that have not been exported yet, and stops the provider
+func (t *Tracing) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+
+	return errors.Join(t.Provider.Shutdown(ctx), t.closer.Close())
+}
+
+// Middleware continues the trace in the traceparent header of the request, or starts a new
+// one, and records a server span for the request. The span is named after the chi route
+// pattern, e.g. GET /users/{id}, once the request has been routed. It returns next unchanged
+// when t is nil, i.e. when tracing is disabled
+func (t *Tracing) Middleware(next http.Handler) http.Handler {
+	if t == nil {
+		return next
+	}
+
+	tracer := t.Provider.Tracer(instrumentation)
+	propagator := otel.GetTextMapPropagator()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				semconv.ClientAddress(r.RemoteAddr),
+				semconv.UserAgentOriginal(r.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			span.SetName(r.Method + " " + rctx.RoutePattern())
+			span.SetAttributes(semconv.HTTPRoute(rctx.RoutePattern()))
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// TraceID returns the id of the trace ctx belongs to, or an empty string if there is none
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error {
+	return nil
+}
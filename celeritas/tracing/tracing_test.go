@@ -0,0 +1,149 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	_ "modernc.org/sqlite"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// exportedSpan is the part of a span written by the file exporter that the tests look at
+type exportedSpan struct {
+	Name        string
+	SpanContext struct {
+		TraceID string
+		SpanID  string
+	}
+	Parent struct {
+		TraceID string
+		SpanID  string
+	}
+}
+
+// readSpans flushes t, and returns the spans written to file
+func readSpans(t *testing.T, tr *Tracing, file string) []exportedSpan {
+	err := tr.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var spans []exportedSpan
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		var s exportedSpan
+		if err := dec.Decode(&s); err != nil {
+			t.Fatal(err)
+		}
+		spans = append(spans, s)
+	}
+
+	return spans
+}
+
+func TestNew_UnknownExporter(t *testing.T) {
+	_, err := New(Options{Exporter: "jaeger"})
+	if err == nil {
+		t.Error("expected an error for an unknown exporter")
+	}
+}
+
+func TestTracing_Middleware(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "logs", "traces.json")
+	tr, err := New(Options{Exporter: "file", File: file, ServiceName: "test", SampleRatio: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var traceID string
+	mux := chi.NewRouter()
+	mux.Use(tr.Middleware)
+	mux.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		traceID = TraceID(r.Context())
+	})
+
+	r := httptest.NewRequest("GET", "/users/1", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+
+	if traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Error("expected the trace in traceparent to be continued, got", traceID)
+	}
+
+	spans := readSpans(t, tr, file)
+	if len(spans) != 1 {
+		t.Fatal("expected 1 span, got", len(spans))
+	}
+
+	if spans[0].Name != "GET /users/{id}" {
+		t.Error("expected the span to be named by route pattern, got", spans[0].Name)
+	}
+	if spans[0].Parent.SpanID != "00f067aa0ba902b7" {
+		t.Error("expected the caller's span as parent, got", spans[0].Parent.SpanID)
+	}
+}
+
+func TestTracing_MiddlewareDisabled(t *testing.T) {
+	var tr *Tracing
+	handler := http.NotFoundHandler()
+
+	if h := tr.Middleware(handler); h == nil {
+		t.Error("expected the handler to be returned when tracing is disabled")
+	}
+
+	if err := tr.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOpenDB(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "traces.json")
+	tr, err := New(Options{Exporter: "file", File: file, ServiceName: "test", SampleRatio: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := OpenDB("sqlite", filepath.Join(t.TempDir(), "test.db"), "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// outside a trace, nothing is recorded
+	_, err = db.Exec("create table users (id integer)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, span := tr.Provider.Tracer("test").Start(context.Background(), "request")
+	_, err = db.ExecContext(ctx, "insert into users (id) values (1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	span.End()
+
+	var queries int
+	for _, s := range readSpans(t, tr, file) {
+		if strings.HasPrefix(s.Name, "sql.") {
+			queries++
+			if s.Parent.SpanID != span.SpanContext().SpanID().String() {
+				t.Error("expected", s.Name, "to be part of the request trace")
+			}
+		}
+	}
+
+	if queries == 0 {
+		t.Error("expected the query in the trace to be recorded")
+	}
+}
@@ -0,0 +1,274 @@
+package celeritas
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/fouched/celeritas/filesystem"
+	"github.com/fouched/celeritas/i18n"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// UploadOptions limit the files accepted by UploadFile. Empty values are taken from the
+// UPLOAD_* settings
+type UploadOptions struct {
+	MaxSize      int64    // per file, in bytes
+	AllowedTypes []string // detected from the contents of the file, e.g. image/png or image/*
+	Disk         string   // the disk the files are stored on; the default disk when empty
+	Multiple     bool     // accept more than one file in the field
+	MaxFiles     int      // when Multiple, defaults to 10
+	Required     bool     // an empty field is an error
+	Label        string   // names the field in error messages; defaults to the field name
+}
+
+// UploadedFile describes a file stored by UploadFile
+type UploadedFile struct {
+	Name         string // where the file is stored on Disk, e.g. avatars/9f86d081884c7d65.png
+	Disk         string
+	OriginalName string // as named by the client; never use it as a path
+	Size         int64
+	ContentType  string // detected from the contents of the file
+}
+
+// UploadError is a problem with an uploaded file that the user can fix, e.g. a file that is
+// too large. Message is in English, with placeholders for Args, and Key is its translation
+type UploadError struct {
+	Field   string
+	Key     string
+	Message string
+	Args    map[string]interface{}
+}
+
+func (e *UploadError) Error() string {
+	return i18n.Format(e.Message, e.Args)
+}
+
+// preferredExtensions name stored files when the client's extension does not match the
+// contents, because mime.ExtensionsByType lists extensions alphabetically, e.g. .jfif for jpeg
+var preferredExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+	"text/plain":      ".txt",
+	"application/zip": ".zip",
+}
+
+// UploadFile stores the files uploaded in field of a multipart form under destination on a
+// disk, with random names. Every file is checked before any is stored, and files that are too
+// large, or whose contents are not of an allowed type, are reported as an *UploadError, which
+// Validation.Upload adds to the form errors, e.g.
+//
+//	files, err := a.App.UploadFile(r, "avatar", "avatars", celeritas.UploadOptions{Required: true})
+//	if err != nil && !validator.Upload(err) {
+//		a.App.Error500(w)
+//		return
+//	}
+func (c *Celeritas) UploadFile(r *http.Request, field, destination string, opts UploadOptions) ([]UploadedFile, error) {
+	opts = c.uploadDefaults(field, opts)
+
+	headers, err := c.uploadedFiles(r, field, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(headers) == 0 {
+		if opts.Required {
+			return nil, &UploadError{Field: field, Key: "validation.upload_required", Message: "{field} is required",
+				Args: map[string]interface{}{"field": opts.Label}}
+		}
+		return nil, nil
+	}
+
+	if !opts.Multiple && len(headers) > 1 {
+		return nil, &UploadError{Field: field, Key: "validation.upload_single", Message: "{field} accepts only one file",
+			Args: map[string]interface{}{"field": opts.Label}}
+	}
+
+	if len(headers) > opts.MaxFiles {
+		return nil, &UploadError{Field: field, Key: "validation.upload_too_many", Message: "{field} accepts at most {max} files",
+			Args: map[string]interface{}{"field": opts.Label, "max": opts.MaxFiles}}
+	}
+
+	files := make([]UploadedFile, len(headers))
+	for i, h := range headers {
+		files[i], err = checkUpload(field, h, opts)
+		if err != nil {
+			return nil, err
+		}
+		files[i].Disk = opts.Disk
+		files[i].Name = path.Join(destination, randomName()+extension(files[i].OriginalName, files[i].ContentType))
+	}
+
+	disk, err := c.Disk(opts.Disk)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, h := range headers {
+		err = storeUpload(r, disk, h, files[i])
+		if err != nil {
+			// do not leave some of the files behind
+			for _, stored := range files[:i] {
+				_ = disk.Delete(r.Context(), stored.Name)
+			}
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+func (c *Celeritas) uploadDefaults(field string, opts UploadOptions) UploadOptions {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = c.config.Upload.MaxSize
+	}
+	if len(opts.AllowedTypes) == 0 {
+		opts.AllowedTypes = c.config.Upload.AllowedTypes
+	}
+	if opts.Disk == "" && c.Disks != nil {
+		opts.Disk = c.Disks.Default
+	}
+	if opts.MaxFiles <= 0 {
+		opts.MaxFiles = 1
+		if opts.Multiple {
+			opts.MaxFiles = 10
+		}
+	}
+	if opts.Label == "" {
+		opts.Label = field
+	}
+
+	return opts
+}
+
+// uploadedFiles parses the multipart form, unless the handler already did, limiting the
+// request to the files it may contain, and returns the files in field
+func (c *Celeritas) uploadedFiles(r *http.Request, field string, opts UploadOptions) ([]*multipart.FileHeader, error) {
+	if r.MultipartForm == nil {
+		// room for the other fields of the form, besides the files
+		r.Body = http.MaxBytesReader(nil, r.Body, opts.MaxSize*int64(opts.MaxFiles)+1<<20)
+
+		err := r.ParseMultipartForm(32 << 20)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, &UploadError{Field: field, Key: "validation.upload_too_large", Message: "{field} is larger than {max}",
+				Args: map[string]interface{}{"field": opts.Label, "max": formatSize(opts.MaxSize)}}
+		} else if errors.Is(err, http.ErrNotMultipart) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return r.MultipartForm.File[field], nil
+}
+
+// checkUpload checks the size and the contents of an uploaded file
+func checkUpload(field string, h *multipart.FileHeader, opts UploadOptions) (UploadedFile, error) {
+	f := UploadedFile{OriginalName: filepath.Base(strings.ReplaceAll(h.Filename, "\\", "/")), Size: h.Size}
+	args := map[string]interface{}{"field": opts.Label, "file": f.OriginalName, "max": formatSize(opts.MaxSize)}
+
+	if h.Size > opts.MaxSize {
+		return f, &UploadError{Field: field, Key: "validation.upload_too_large", Message: "{file} is larger than {max}", Args: args}
+	}
+
+	file, err := h.Open()
+	if err != nil {
+		return f, err
+	}
+	defer file.Close()
+
+	// the type is sniffed from the first 512 bytes, the client's Content-Type is not trusted
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return f, err
+	}
+
+	f.ContentType, _, _ = mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if !allowedType(f.ContentType, opts.AllowedTypes) {
+		return f, &UploadError{Field: field, Key: "validation.upload_type", Message: "{file} is not an allowed type of file", Args: args}
+	}
+
+	return f, nil
+}
+
+// allowedType reports whether contentType is one of allowed, which may end in /*
+func allowedType(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	for _, a := range allowed {
+		if prefix, ok := strings.CutSuffix(a, "/*"); ok {
+			if strings.HasPrefix(contentType, prefix+"/") {
+				return true
+			}
+		} else if strings.EqualFold(a, contentType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func storeUpload(r *http.Request, disk filesystem.FS, h *multipart.FileHeader, f UploadedFile) error {
+	file, err := h.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return disk.Put(r.Context(), f.Name, file, filesystem.PutOptions{ContentType: f.ContentType})
+}
+
+// randomName returns a name that cannot be guessed, or collide with another upload
+func randomName() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// extension keeps the extension of the original name if it matches the contents of the file
+func extension(originalName, contentType string) string {
+	ext := strings.ToLower(path.Ext(originalName))
+	if extensions, err := mime.ExtensionsByType(contentType); err == nil {
+		for _, e := range extensions {
+			if e == ext {
+				return ext
+			}
+		}
+	}
+
+	if ext, ok := preferredExtensions[contentType]; ok {
+		return ext
+	}
+
+	if extensions, err := mime.ExtensionsByType(contentType); err == nil && len(extensions) > 0 {
+		return extensions[0]
+	}
+
+	return ""
+}
+
+// formatSize formats a number of bytes for error messages, e.g. 2 MB
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%d MB", n>>20)
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%d KB", n>>10)
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
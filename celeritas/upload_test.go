@@ -0,0 +1,123 @@
+package celeritas
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+)
+
+// pngHeader is enough of a PNG for its type to be detected
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type upload struct {
+	field, name string
+	content     []byte
+}
+
+func uploadRequest(t *testing.T, files ...upload) *http.Request {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	_ = mw.WriteField("title", "holiday")
+	for _, f := range files {
+		part, err := mw.CreateFormFile(f.field, f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = part.Write(f.content)
+	}
+	_ = mw.Close()
+
+	r := httptest.NewRequest("POST", "/upload", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+var uploadTests = []struct {
+	name    string
+	files   []upload
+	opts    UploadOptions
+	stored  int
+	problem string // the form error expected, if any
+}{
+	{"png", []upload{{"photo", "me.png", pngHeader}}, UploadOptions{}, 1, ""},
+	{"wrong-extension", []upload{{"photo", "me.jpg", pngHeader}}, UploadOptions{}, 1, ""},
+	{"sniffed-not-extension", []upload{{"photo", "evil.png", []byte("<html><script>alert(1)</script>")}}, UploadOptions{}, 0, "evil.png is not an allowed type of file"},
+	{"too-large", []upload{{"photo", "me.png", append(pngHeader, make([]byte, 100)...)}}, UploadOptions{MaxSize: 50}, 0, "me.png is larger than 50 bytes"},
+	{"body-too-large", []upload{{"photo", "me.png", append(pngHeader, make([]byte, 4<<20)...)}}, UploadOptions{MaxSize: 1 << 20}, 0, "photo is larger than 1 MB"},
+	{"optional", nil, UploadOptions{}, 0, ""},
+	{"required", nil, UploadOptions{Required: true, Label: "Photo"}, 0, "Photo is required"},
+	{"single", []upload{{"photo", "a.png", pngHeader}, {"photo", "b.png", pngHeader}}, UploadOptions{}, 0, "photo accepts only one file"},
+	{"multiple", []upload{{"photo", "a.png", pngHeader}, {"photo", "b.png", pngHeader}}, UploadOptions{Multiple: true}, 2, ""},
+	{"too-many", []upload{{"photo", "a.png", pngHeader}, {"photo", "b.png", pngHeader}}, UploadOptions{Multiple: true, MaxFiles: 1}, 0, "photo accepts at most 1 files"},
+	{"one-bad-stores-none", []upload{{"photo", "a.png", pngHeader}, {"photo", "b.txt", []byte("hello")}}, UploadOptions{Multiple: true}, 0, "b.txt is not an allowed type of file"},
+	{"allowed-text", []upload{{"photo", "b.txt", []byte("hello")}}, UploadOptions{AllowedTypes: []string{"text/*"}}, 1, ""},
+}
+
+func TestCeleritas_UploadFile(t *testing.T) {
+	for _, e := range uploadTests {
+		c := newDiskCeleritas(t)
+		c.config.Upload = UploadConfig{MaxSize: 10 << 20, AllowedTypes: []string{"image/png", "image/jpeg"}}
+
+		files, err := c.UploadFile(uploadRequest(t, e.files...), "photo", "photos", e.opts)
+
+		v := &Validation{Errors: make(map[string]string)}
+		if err != nil && !v.Upload(err) {
+			t.Errorf("%s: unexpected error %v", e.name, err)
+			continue
+		}
+
+		if v.Errors["photo"] != e.problem {
+			t.Errorf("%s: expected problem %q, got %q", e.name, e.problem, v.Errors["photo"])
+		}
+
+		if len(files) != e.stored {
+			t.Errorf("%s: expected %d files, got %d", e.name, e.stored, len(files))
+		}
+
+		disk, _ := c.Disk("")
+		stored, _ := disk.List(context.Background(), "photos/")
+		if len(stored) != e.stored {
+			t.Errorf("%s: expected %d files on the disk, got %d", e.name, e.stored, len(stored))
+		}
+
+		for i, f := range files {
+			if !strings.HasPrefix(f.Name, "photos/") || path.Base(f.Name) == e.files[i].name {
+				t.Errorf("%s: expected a random name, got %s", e.name, f.Name)
+			}
+			if f.OriginalName != e.files[i].name || f.Size != int64(len(e.files[i].content)) || f.Disk != "local" {
+				t.Errorf("%s: wrong metadata %+v", e.name, f)
+			}
+
+			got, err := disk.Get(context.Background(), f.Name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, _ := io.ReadAll(got)
+			_ = got.Close()
+			if !bytes.Equal(content, e.files[i].content) {
+				t.Errorf("%s: wrong content stored", e.name)
+			}
+		}
+	}
+}
+
+func TestCeleritas_UploadFileExtension(t *testing.T) {
+	c := newDiskCeleritas(t)
+	c.config.Upload = UploadConfig{MaxSize: 1 << 20, AllowedTypes: []string{"image/png"}}
+
+	files, err := c.UploadFile(uploadRequest(t, upload{"photo", "me.JPG", pngHeader}), "photo", "", UploadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(files[0].Name, ".png") || files[0].ContentType != "image/png" {
+		t.Errorf("expected the extension to follow the contents, got %s %s", files[0].Name, files[0].ContentType)
+	}
+}
@@ -1,8 +1,9 @@
 package celeritas
 
 import (
-	"fmt"
+	"errors"
 	"github.com/asaskevich/govalidator"
+	"github.com/fouched/celeritas/i18n"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -11,8 +12,10 @@ import (
 )
 
 type Validation struct {
-	Data   url.Values
-	Errors map[string]string
+	Data     url.Values
+	Errors   map[string]string
+	Locale   string       // the locale error messages are translated into
+	Messages *i18n.Bundle // translations of the error messages, e.g. validation.required
 }
 
 type Field struct {
@@ -25,11 +28,30 @@ type Field struct {
 // You can pass nil to this constructor to use the validation functions without an Http form
 func (c *Celeritas) Validator(data url.Values) *Validation {
 	return &Validation{
-		Data:   data,
-		Errors: make(map[string]string),
+		Data:     data,
+		Errors:   make(map[string]string),
+		Locale:   c.config.I18n.DefaultLocale,
+		Messages: c.I18n,
 	}
 }
 
+// WithLocale translates the error messages into locale, e.g. the locale of the request:
+//
+//	validator := a.App.Validator(r.Form).WithLocale(i18n.Locale(r.Context()))
+func (v *Validation) WithLocale(locale string) *Validation {
+	v.Locale = locale
+	return v
+}
+
+// message translates the error message with key, or formats the English message if there is
+// no translation. Placeholders like {field} are replaced by args
+func (v *Validation) message(key, english string, args ...interface{}) string {
+	if v.Messages != nil && v.Messages.Has(v.Locale, key) {
+		return v.Messages.Translate(v.Locale, key, args...)
+	}
+	return i18n.Format(english, args...)
+}
+
 // Valid returns true if the current Validator contains no errors, otherwise false
 func (v *Validation) Valid() bool {
 	return len(v.Errors) == 0
@@ -52,23 +74,35 @@ func (v *Validation) Has(field string, r *http.Request) bool {
 func (v *Validation) Required(fields ...Field) {
 	for _, field := range fields {
 		if strings.TrimSpace(field.Value) == "" {
-			v.AddError(field.Name, fmt.Sprintf("%s cannot be blank", field.Label))
+			v.AddError(field.Name, v.message("validation.required", "{field} cannot be blank", "field", field.Label))
 		}
 	}
 }
 
+// Upload adds err to the form errors if it is an *UploadError returned by UploadFile, and
+// reports whether it was. Other errors, e.g. a disk that cannot be written, are left to the caller
+func (v *Validation) Upload(err error) bool {
+	var e *UploadError
+	if !errors.As(err, &e) {
+		return false
+	}
+
+	v.AddError(e.Field, v.message(e.Key, e.Message, e.Args))
+	return true
+}
+
 // Check takes any expression that can be evaluated to a bool
-// and adds an error if result is false
+// and adds an error if result is false. The message may be a translation key
 func (v *Validation) Check(ok bool, key, message string) {
 	if !ok {
-		v.AddError(key, message)
+		v.AddError(key, v.message(message, message))
 	}
 }
 
 // IsLength checks if a field is at least a specific length and adds an error if result is false
 func (v *Validation) IsLength(field Field, length int) {
 	if len(strings.TrimSpace(field.Value)) < length {
-		v.AddError(field.Name, fmt.Sprintf("%s must be at least %d characters", field.Label, length))
+		v.AddError(field.Name, v.message("validation.min_length", "{field} must be at least {length} characters", "field", field.Label, "length", length))
 	}
 }
 
@@ -76,7 +110,7 @@ func (v *Validation) IsLength(field Field, length int) {
 func (v *Validation) IsInt(field Field) {
 	_, err := strconv.Atoi(field.Value)
 	if err != nil {
-		v.AddError(field.Name, fmt.Sprintf("%s must be an integer", field.Label))
+		v.AddError(field.Name, v.message("validation.integer", "{field} must be an integer", "field", field.Label))
 	}
 }
 
@@ -84,7 +118,7 @@ func (v *Validation) IsInt(field Field) {
 func (v *Validation) IsFloat(field Field) {
 	_, err := strconv.ParseFloat(field.Value, 64)
 	if err != nil {
-		v.AddError(field.Name, fmt.Sprintf("%s must contain decimal values", field.Label))
+		v.AddError(field.Name, v.message("validation.float", "{field} must contain decimal values", "field", field.Label))
 	}
 }
 
@@ -92,7 +126,7 @@ func (v *Validation) IsFloat(field Field) {
 func (v *Validation) IsDateISO(field Field) {
 	_, err := time.Parse(time.DateOnly, field.Value)
 	if err != nil {
-		v.AddError(field.Name, fmt.Sprintf("%s must be a date in YYYY-MM-DD format", field.Label))
+		v.AddError(field.Name, v.message("validation.date", "{field} must be a date in YYYY-MM-DD format", "field", field.Label))
 	}
 }
 
@@ -101,13 +135,13 @@ func (v *Validation) IsDateISO(field Field) {
 // IsEmail checks if a Field contains a valid email and adds an error if result is false
 func (v *Validation) IsEmail(field Field) {
 	if !govalidator.IsEmail(field.Value) {
-		v.AddError(field.Name, fmt.Sprintf("%s must be a valid email address", field.Label))
+		v.AddError(field.Name, v.message("validation.email", "{field} must be a valid email address", "field", field.Label))
 	}
 }
 
 // NoSpaces checks if a Field contains spaces and adds an error if result is false
 func (v *Validation) NoSpaces(field Field) {
 	if govalidator.HasWhitespace(field.Value) {
-		v.AddError(field.Name, fmt.Sprintf("%s does not allow spaces", field.Label))
+		v.AddError(field.Name, v.message("validation.no_spaces", "{field} does not allow spaces", "field", field.Label))
 	}
 }
@@ -97,7 +97,7 @@ func TestMain(m *testing.M) {
 		log.Fatalf("error creating tables: %s", err)
 	}
 
-	models = New(testDB)
+	models = New(testDB, "postgres")
 
 	code := m.Run()
 
@@ -6,7 +6,7 @@ import (
 	up "github.com/upper/db/v4"
 	"github.com/upper/db/v4/adapter/mysql"
 	"github.com/upper/db/v4/adapter/postgresql"
-	"os"
+	"github.com/upper/db/v4/adapter/sqlite"
 )
 
 var db *sql.DB
@@ -19,16 +19,20 @@ type Models struct {
 	Tokens Token
 }
 
-func New(databasePool *sql.DB) Models {
+// New creates the models, using the upper adapter for databaseType, i.e. the DATABASE_TYPE
+// Celeritas connected with
+func New(databasePool *sql.DB, databaseType string) Models {
 	db = databasePool
 
-	switch os.Getenv("DATABASE_TYPE") {
+	switch databaseType {
 	case "mysql", "mariadb":
 		upper, _ = mysql.New(db)
 	case "postgres", "postgresql":
 		upper, _ = postgresql.New(db)
+	case "sqlite":
+		upper, _ = sqlite.New(db)
 	default:
-		//do nothing - maybe use sql lite ?
+		//do nothing
 	}
 
 	return Models{
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
 	db2 "github.com/upper/db/v4"
-	"os"
 	"testing"
 )
 
@@ -12,16 +11,11 @@ func TestNew(t *testing.T) {
 	fakeDB, _, _ := sqlmock.New()
 	defer fakeDB.Close()
 
-	_ = os.Setenv("DATABASE_TYPE", "postgres")
-	m := New(fakeDB)
-	if fmt.Sprintf("%T", m) != "data.Models" {
-		t.Error("wrong type", fmt.Sprintf("%T", m))
-	}
-
-	_ = os.Setenv("DATABASE_TYPE", "mysql")
-	m = New(fakeDB)
-	if fmt.Sprintf("%T", m) != "data.Models" {
-		t.Error("wrong type", fmt.Sprintf("%T", m))
+	for _, databaseType := range []string{"postgres", "mysql", "sqlite"} {
+		m := New(fakeDB, databaseType)
+		if fmt.Sprintf("%T", m) != "data.Models" {
+			t.Error("wrong type", fmt.Sprintf("%T", m))
+		}
 	}
 }
 
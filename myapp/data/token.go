@@ -107,6 +107,18 @@ func (t *Token) DeleteByToken(plainText string) error {
 	return nil
 }
 
+// DeleteExpired deletes all tokens that have expired
+func (t *Token) DeleteExpired() error {
+	collection := upper.Collection(t.Table())
+	rs := collection.Find(up.Cond{"expiry <": time.Now().UTC()})
+	err := rs.Delete()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (t *Token) Insert(token Token, u User) error {
 	collection := upper.Collection(t.Table())
 
@@ -0,0 +1,9 @@
+package main
+
+import "embed"
+
+// files are built into the binary, so that a deploy only needs the binary and .env. With
+// DEBUG=true they are read from disk instead, so that changes show without a rebuild
+//
+//go:embed views mail lang public
+var files embed.FS
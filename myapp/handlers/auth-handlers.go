@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"github.com/CloudyKit/jet/v6"
+	"github.com/fouched/celeritas"
 	"github.com/fouched/celeritas/mailer"
 	"github.com/fouched/celeritas/urlsigner"
 	"myapp/data"
@@ -31,6 +32,7 @@ func (h *Handlers) UserLoginPost(w http.ResponseWriter, r *http.Request) {
 
 	user, err := h.Models.Users.GetByEmail(email)
 	if err != nil {
+		h.App.Events.Publish(r.Context(), celeritas.LoginFailed{Email: email, IP: r.RemoteAddr, Reason: "unknown user"})
 		h.App.ErrorLog.Println(err.Error())
 		return
 	}
@@ -42,6 +44,7 @@ func (h *Handlers) UserLoginPost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if !matches {
+		h.App.Events.Publish(r.Context(), celeritas.LoginFailed{Email: email, IP: r.RemoteAddr, Reason: "invalid password"})
 		h.App.ErrorLog.Println("Invalid password")
 		return
 	}
@@ -164,10 +167,20 @@ func (h *Handlers) ForgotPost(w http.ResponseWriter, r *http.Request) {
 		Data:     emailData,
 	}
 
-	h.App.Mail.Jobs <- msg
-	res := <-h.App.Mail.Results
-	if res.Error != nil {
-		h.App.ErrorStatus(w, http.StatusBadRequest)
+	reply, err := h.App.Mail.Queue(msg)
+	if err != nil {
+		h.App.ErrorStatus(w, http.StatusServiceUnavailable)
+		return
+	}
+
+	select {
+	case res := <-reply:
+		if res.Error != nil {
+			h.App.ErrorStatus(w, http.StatusBadRequest)
+			return
+		}
+	case <-time.After(30 * time.Second):
+		h.App.ErrorStatus(w, http.StatusGatewayTimeout)
 		return
 	}
 
@@ -242,6 +255,8 @@ func (h *Handlers) ResetPasswordPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.App.Events.Publish(r.Context(), celeritas.PasswordReset{UserID: user.ID, Email: user.Email})
+
 	// redirect
 	h.App.Session.Put(r.Context(), "flash", "Password reset. You can now log in.")
 	http.Redirect(w, r, "/users/login", http.StatusSeeOther)
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"github.com/fouched/celeritas"
 	"log"
 	"myapp/data"
 	"myapp/handlers"
 	"myapp/middleware"
+	"net/http"
 	"os"
 )
 
@@ -16,7 +19,7 @@ func initApplication() *application {
 	}
 
 	// init celeritas
-	cel := &celeritas.Celeritas{}
+	cel := &celeritas.Celeritas{Files: files}
 	err = cel.New(path)
 	if err != nil {
 		log.Fatal(err)
@@ -40,9 +43,19 @@ func initApplication() *application {
 	app.App.Routes = app.routes()
 
 	// set the models
-	app.Models = data.New(app.App.DB.Pool)
+	app.Models = data.New(app.App.DB.Pool, app.App.DB.Type)
 	myHandlers.Models = app.Models
 	app.Middleware.Models = app.Models
 
+	// scheduled tasks
+	app.App.Scheduler.Call("tokens:cleanup", func(ctx context.Context) error {
+		return app.Models.Tokens.DeleteExpired()
+	}).DailyAt("03:00")
+
+	// users may only listen to their own private channel
+	app.App.Broadcast.Authorize("private-user.*", func(r *http.Request, userID int, channel string) bool {
+		return channel == fmt.Sprintf("private-user.%d", userID)
+	})
+
 	return app
 }
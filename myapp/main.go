@@ -2,14 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/fouched/celeritas"
+	"log"
 	"myapp/data"
 	"myapp/handlers"
 	"myapp/middleware"
 	"os"
-	"os/signal"
-	"syscall"
 )
 
 type application struct {
@@ -20,17 +20,22 @@ type application struct {
 }
 
 func main() {
-	// Create context that listens for the interrupt signal from the OS
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
+	c := initApplication()
 
-	// Start the application
-	go func() {
-		c := initApplication()
-		c.App.ListenAndServe()
-	}()
+	// run a command added by celeritas or a provider instead of serving, e.g. go run . list
+	if len(os.Args) > 1 {
+		err := errors.Join(c.App.RunCommand(os.Args[1:]), c.App.Shutdown(context.Background()))
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// blocks until the app receives an interrupt signal and has shut down gracefully
+	err := c.App.ListenAndServe()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	// Listen for the interrupt signal to complete, giving defer statements time to run
-	<-ctx.Done()
 	fmt.Println("Graceful shutdown complete")
 }
@@ -2,11 +2,14 @@ package main
 
 import (
 	"fmt"
+	"github.com/fouched/celeritas"
 	"github.com/fouched/celeritas/mailer"
+	"github.com/fouched/celeritas/ratelimit"
 	"github.com/go-chi/chi/v5"
 	"myapp/data"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 func (a *application) routes() *chi.Mux {
@@ -22,7 +25,9 @@ func (a *application) routes() *chi.Mux {
 	a.get("/sessions", a.Handlers.SessionTest)
 
 	a.get("/users/login", a.Handlers.UserLoginGet)
-	a.post("/users/login", a.Handlers.UserLoginPost)
+	// slow down password guessing
+	loginLimit := a.App.RateLimit(ratelimit.Limit{Name: "login", Requests: 5, Per: time.Minute})
+	a.App.Routes.With(loginLimit).Post("/users/login", a.Handlers.UserLoginPost)
 	a.get("/users/logout", a.Handlers.LogOut)
 	a.get("/users/forgot-password", a.Handlers.ForgotGet)
 	a.post("/users/forgot-password", a.Handlers.ForgotPost)
@@ -82,6 +87,8 @@ func (a *application) routes() *chi.Mux {
 			return
 		}
 
+		a.App.Events.Publish(r.Context(), celeritas.UserRegistered{UserID: id, Email: u.Email})
+
 		fmt.Fprintf(w, "%d: %s", id, u.FirstName)
 	})
 
@@ -136,9 +143,11 @@ func (a *application) routes() *chi.Mux {
 		fmt.Fprintf(w, "%s %s %s", u.FirstName, u.LastName, u.Email)
 	})
 
+	// real-time broadcasting over WebSockets and server-sent events
+	a.App.Routes.Mount("/broadcasting", a.App.Broadcast.Routes())
+
 	// static routes
-	fileServer := http.FileServer(http.Dir("./public"))
-	a.App.Routes.Handle("/public/*", http.StripPrefix("/public", fileServer))
+	a.App.Routes.Handle("/public/*", http.StripPrefix("/public", a.App.PublicFiles()))
 
 	return a.App.Routes
 }